@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// validateArtist checks the fields an artist added through the admin endpoint must have
+// the name is required, the id must be positive, the creation date a plausible year and the first album a DD-MM-YYYY or YYYY date
+func validateArtist(artist Artists) error {
+	switch {
+	case artist.Name == "":
+		return errors.New("name is required")
+	case artist.ID <= 0:
+		return errors.New("id must be a positive integer")
+	case artist.CreationDate < 1900 || artist.CreationDate > time.Now().Year():
+		return errors.New("creationDate must be a year between 1900 and now")
+	case parseFirstAlbum(artist.FirstAlbum).IsZero():
+		return errors.New("firstAlbum must be a DD-MM-YYYY or YYYY date")
+	}
+	return nil
+}
+
+// AdminAddArtistHandler adds the artist in the JSON body to the live artists and responds with it and a 201
+// the body has the shape of Artists, its DatesLocations are ignored, an artist with an id already in use is a 409
+func (a *App) AdminAddArtistHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var artist Artists
+	if err := json.NewDecoder(r.Body).Decode(&artist); err != nil {
+		if isBodyTooLarge(err) {
+			writeJSONError(w, r, http.StatusRequestEntityTooLarge, "Request body too large")
+			return
+		}
+		writeJSONError(w, r, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+	if err := validateArtist(artist); err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	artist.DatesLocations = Relations{ID: artist.ID}
+	artist.RelationsURL = ""
+	artist.FirstAlbumDate = parseFirstAlbum(artist.FirstAlbum)
+
+	added := false
+	a.updateArtists(func() bool {
+		if _, found := findArtist(a.Artists, artist.ID); found {
+			return false
+		}
+		a.customArtists = append(a.customArtists, artist)
+		delete(a.deletedArtists, artist.ID)
+		added = true
+		return true
+	})
+	if !added {
+		writeJSONError(w, r, http.StatusConflict, "An artist with this id already exists")
+		return
+	}
+
+	user, _, _ := r.BasicAuth()
+	slog.InfoContext(r.Context(), "artist added", slog.Int("id", artist.ID), slog.String("name", artist.Name), slog.String("by", user))
+	w.Header().Set("Location", "/api/artists/"+strconv.Itoa(artist.ID))
+	writeEnvelope(w, r, http.StatusCreated, artist, APIMeta{Total: 1})
+}
+
+// removeArtist returns a copy of artists without the one with the given id and whether it was found
+func removeArtist(artists []Artists, id int) ([]Artists, bool) {
+	filtered := make([]Artists, 0, len(artists))
+	found := false
+	for _, artist := range artists {
+		if artist.ID == id {
+			found = true
+			continue
+		}
+		filtered = append(filtered, artist)
+	}
+	return filtered, found
+}
+
+// AdminDeleteArtistHandler removes the artist whose id is in the /admin/artists/{id} path and responds with a 204
+// a custom artist is gone for good, an API artist is hidden so it doesn't come back with the next refresh
+func (a *App) AdminDeleteArtistHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/admin/artists/"))
+	if err != nil {
+		writeJSONError(w, r, http.StatusNotFound, "Artist not found")
+		return
+	}
+
+	var removed Artists
+	a.updateArtists(func() bool {
+		artist, found := findArtist(a.Artists, id)
+		if !found {
+			return false
+		}
+		removed = artist
+		a.customArtists, _ = removeArtist(a.customArtists, id)
+		// an API artist is hidden too, even when a local artist was overriding it
+		if _, fromAPI := findArtist(a.apiArtists, id); fromAPI {
+			if a.deletedArtists == nil {
+				a.deletedArtists = make(map[int]bool)
+			}
+			a.deletedArtists[id] = true
+		}
+		return true
+	})
+	if removed.ID == 0 {
+		writeJSONError(w, r, http.StatusNotFound, "Artist not found")
+		return
+	}
+
+	user, _, _ := r.BasicAuth()
+	slog.InfoContext(r.Context(), "artist deleted", slog.Int("id", removed.ID), slog.String("name", removed.Name), slog.String("by", user))
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateArtist(t *testing.T) {
+	valid := Artists{ID: 100, Name: "The Weeknd", CreationDate: 2010, FirstAlbum: "21-03-2011"}
+	tests := []struct {
+		name    string
+		edit    func(*Artists)
+		wantErr string
+	}{
+		{name: "valid", edit: func(*Artists) {}},
+		{name: "year only album", edit: func(a *Artists) { a.FirstAlbum = "2011" }},
+		{name: "no name", edit: func(a *Artists) { a.Name = "" }, wantErr: "name"},
+		{name: "zero id", edit: func(a *Artists) { a.ID = 0 }, wantErr: "id"},
+		{name: "negative id", edit: func(a *Artists) { a.ID = -1 }, wantErr: "id"},
+		{name: "old creation date", edit: func(a *Artists) { a.CreationDate = 1899 }, wantErr: "creationDate"},
+		{name: "future creation date", edit: func(a *Artists) { a.CreationDate = time.Now().Year() + 1 }, wantErr: "creationDate"},
+		{name: "bad album date", edit: func(a *Artists) { a.FirstAlbum = "2011-03-21" }, wantErr: "firstAlbum"},
+		{name: "no album date", edit: func(a *Artists) { a.FirstAlbum = "" }, wantErr: "firstAlbum"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			artist := valid
+			tt.edit(&artist)
+			err := validateArtist(artist)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateArtist = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateArtist = %v, want an error about %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// postArtist sends body to POST /admin/artists, with the Basic Auth credentials unless user is empty
+func postArtist(handler http.Handler, user, password, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/admin/artists", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if user != "" {
+		r.SetBasicAuth(user, password)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestAdminAddArtistHandler(t *testing.T) {
+	app := newTestApp(t, testArtists())
+	app.Config.AdminUser, app.Config.AdminPassword = "admin", "secret"
+	handler := newTestServer(t, app)
+	body := `{"id": 100, "name": "The Weeknd", "members": ["Abel Tesfaye"], "creationDate": 2010, "firstAlbum": "21-03-2011"}`
+
+	t.Run("no credentials", func(t *testing.T) {
+		w := postArtist(handler, "", "", body)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+		if w.Header().Get("WWW-Authenticate") == "" {
+			t.Error("no WWW-Authenticate header")
+		}
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		w := postArtist(handler, "admin", "wrong", body)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("invalid artist", func(t *testing.T) {
+		w := postArtist(handler, "admin", "secret", `{"id": 101, "creationDate": 2010, "firstAlbum": "2011"}`)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		w := postArtist(handler, "admin", "secret", `{"id":`)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("added", func(t *testing.T) {
+		w := postArtist(handler, "admin", "secret", body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body)
+		}
+		created := decodeEnvelope[Artists](t, w)
+		if created.Data.Name != "The Weeknd" || created.Data.FirstAlbumDate.Year() != 2011 {
+			t.Errorf("created %+v", created.Data)
+		}
+		if w.Header().Get("Location") != "/api/artists/100" {
+			t.Errorf("Location = %q", w.Header().Get("Location"))
+		}
+		if _, found := findArtist(app.artists(), 100); !found {
+			t.Error("the artist isn't in the live artists")
+		}
+	})
+
+	t.Run("duplicate id", func(t *testing.T) {
+		w := postArtist(handler, "admin", "secret", body)
+		if w.Code != http.StatusConflict {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusConflict)
+		}
+	})
+}
+
+func TestAdminAuthWithoutCredentials(t *testing.T) {
+	// without configured credentials the admin endpoints refuse every request
+	handler := newTestServer(t, newTestApp(t, testArtists()))
+	w := postArtist(handler, "admin", "", `{"id": 100, "name": "The Weeknd", "creationDate": 2010, "firstAlbum": "2011"}`)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
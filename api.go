@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// APIEnvelope is the envelope every /api/ response is wrapped in so callers always get the same shape
+// Data is the payload, Error is only set when the request failed
+type APIEnvelope[T any] struct {
+	Data  T         `json:"data"`
+	Meta  APIMeta   `json:"meta"`
+	Error *APIError `json:"error,omitempty"`
+}
+
+// APIMeta describes the payload of an APIEnvelope, Page and PerPage are only set by the paginated listings
+type APIMeta struct {
+	Total     int    `json:"total"`
+	Page      int    `json:"page,omitempty"`
+	PerPage   int    `json:"perPage,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// APIError is the error of a failed API request, Code repeats the HTTP status code
+type APIError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// HealthStatus is the body returned by the /health and /ready probes
+type HealthStatus struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+	// MissingTemplates lists the required templates that aren't loaded when that's why the server isn't ready
+	MissingTemplates []string `json:"missingTemplates,omitempty"`
+}
+
+// writeJSON marshals payload and writes it with the given status code
+// the payload is marshalled before anything is written so a marshalling failure can still be reported as a 500
+func writeJSON(w http.ResponseWriter, code int, payload interface{}) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error marshalling JSON response: %v", err)
+		code = http.StatusInternalServerError
+		body = []byte(`{"data":null,"meta":{"total":0},"error":{"code":500,"message":"Internal server error"}}`)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(body)
+}
+
+// writeEnvelope wraps data in an APIEnvelope carrying meta and the id of the request, then writes it
+func writeEnvelope[T any](w http.ResponseWriter, r *http.Request, code int, data T, meta APIMeta) {
+	meta.RequestID = requestIDFromContext(r.Context())
+	writeJSON(w, code, APIEnvelope[T]{Data: data, Meta: meta})
+}
+
+// writeJSONError writes an APIEnvelope carrying only an error message
+func writeJSONError(w http.ResponseWriter, r *http.Request, code int, message string) {
+	writeJSON(w, code, APIEnvelope[any]{
+		Meta:  APIMeta{RequestID: requestIDFromContext(r.Context())},
+		Error: &APIError{Code: code, Message: message},
+	})
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// decodeEnvelope checks that w holds a JSON APIEnvelope and returns it with its data decoded as T
+func decodeEnvelope[T any](t *testing.T, w *httptest.ResponseRecorder) APIEnvelope[T] {
+	t.Helper()
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "application/json") {
+		t.Fatalf("Content-Type = %q, want application/json", contentType)
+	}
+	var envelope APIEnvelope[T]
+	if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("decoding the envelope %s: %v", w.Body, err)
+	}
+	return envelope
+}
+
+// jsonHeader is the header of the requests to the JSON API
+var jsonHeader = http.Header{"Accept": {"application/json"}}
+
+func TestAPIEnvelope(t *testing.T) {
+	handler := newTestServer(t, newTestApp(t, testArtists()))
+
+	t.Run("data", func(t *testing.T) {
+		w := serve(handler, http.MethodGet, "/api/artists/1", jsonHeader)
+		envelope := decodeEnvelope[Artists](t, w)
+		if w.Code != http.StatusOK || envelope.Error != nil {
+			t.Fatalf("status = %d and error = %+v, want %d and none", w.Code, envelope.Error, http.StatusOK)
+		}
+		if envelope.Data.Name != "Queen" || envelope.Meta.Total != 1 {
+			t.Errorf("data = %+v and meta = %+v", envelope.Data, envelope.Meta)
+		}
+		if envelope.Meta.RequestID == "" || envelope.Meta.RequestID != w.Header().Get("X-Request-ID") {
+			t.Errorf("requestId = %q, want the X-Request-ID %q", envelope.Meta.RequestID, w.Header().Get("X-Request-ID"))
+		}
+	})
+
+	// every kind of failure has the same error schema
+	failures := []struct {
+		method, target string
+		want           int
+	}{
+		{http.MethodGet, "/api/artists/42", http.StatusNotFound},
+		{http.MethodGet, "/api/no-such-endpoint", http.StatusNotFound},
+		{http.MethodGet, "/api/artists?sort=nope", http.StatusBadRequest},
+		{http.MethodDelete, "/api/artists", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range failures {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			w := serve(handler, tt.method, tt.target, jsonHeader)
+			envelope := decodeEnvelope[any](t, w)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+			if envelope.Error == nil || envelope.Error.Code != tt.want || envelope.Error.Message == "" {
+				t.Errorf("error = %+v, want code %d and a message", envelope.Error, tt.want)
+			}
+			if envelope.Data != nil {
+				t.Errorf("data = %v, want null", envelope.Data)
+			}
+			if envelope.Meta.RequestID == "" {
+				t.Error("meta has no requestId")
+			}
+		})
+	}
+}
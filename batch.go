@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// maxBatchIDs is the most artists a single /api/artists/batch request can ask for
+const maxBatchIDs = 50
+
+// BatchRequest is the JSON body of /api/artists/batch
+type BatchRequest struct {
+	IDs []int `json:"ids"`
+}
+
+// BatchFetch returns the artists with the given ids keyed by id, an id without an artist maps to nil
+func BatchFetch(artists []Artists, ids []int) map[int]*Artists {
+	byID := make(map[int]*Artists, len(artists))
+	for i := range artists {
+		byID[artists[i].ID] = &artists[i]
+	}
+	found := make(map[int]*Artists, len(ids))
+	for _, id := range ids {
+		found[id] = byID[id]
+	}
+	return found
+}
+
+// APIArtistsBatchHandler responds with the artists whose ids are in the JSON body as a JSON object keyed by id
+// the missing ones are null, Total is the number of artists found
+// it's under /api/ so it goes through RequireJSON and the global rate limit like the other API routes
+func (a *App) APIArtistsBatchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var request BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		if isBodyTooLarge(err) {
+			writeJSONError(w, r, http.StatusRequestEntityTooLarge, "Request body too large")
+			return
+		}
+		writeJSONError(w, r, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+	if len(request.IDs) == 0 {
+		writeJSONError(w, r, http.StatusBadRequest, "ids is required")
+		return
+	}
+	if len(request.IDs) > maxBatchIDs {
+		writeJSONError(w, r, http.StatusBadRequest, fmt.Sprintf("At most %d ids per batch", maxBatchIDs))
+		return
+	}
+
+	artists := BatchFetch(a.artists(), request.IDs)
+	total := 0
+	for _, artist := range artists {
+		if artist != nil {
+			total++
+		}
+	}
+	writeEnvelope(w, r, http.StatusOK, artists, APIMeta{Total: total})
+}
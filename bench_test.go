@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// benchArtistCount is the size of the synthetic dataset of the benchmarks
+const benchArtistCount = 1000
+
+var (
+	benchCities    = []string{"london", "paris", "berlin", "tokyo", "sydney", "lyon", "osaka", "dunedin"}
+	benchCountries = []string{"uk", "france", "germany", "japan", "australia", "new_zealand", "usa"}
+	benchWords     = []string{"red", "stone", "night", "echo", "velvet", "iron", "lunar", "wild", "electric", "silver"}
+)
+
+// benchArtists returns n synthetic artists, the same ones every time since they come from a fixed seed
+func benchArtists(n int) []Artists {
+	random := rand.New(rand.NewSource(1))
+	pick := func(words []string) string { return words[random.Intn(len(words))] }
+	artists := make([]Artists, n)
+	for i := range artists {
+		locations := make(map[string][]string)
+		for j := random.Intn(8) + 1; j > 0; j-- {
+			location := pick(benchCities) + "-" + pick(benchCountries)
+			locations[location] = append(locations[location], fmt.Sprintf("%02d-%02d-%d", random.Intn(28)+1, random.Intn(12)+1, 1990+random.Intn(35)))
+		}
+		members := make([]string, random.Intn(5)+1)
+		for j := range members {
+			members[j] = pick(benchWords) + " " + pick(benchWords)
+		}
+		artists[i] = Artists{
+			ID:             i + 1,
+			Name:           fmt.Sprintf("The %s %s %d", pick(benchWords), pick(benchWords), i),
+			Members:        members,
+			CreationDate:   1960 + random.Intn(60),
+			FirstAlbum:     fmt.Sprintf("%02d-%02d-%d", random.Intn(28)+1, random.Intn(12)+1, 1960+random.Intn(60)),
+			DatesLocations: Relations{ID: i + 1, DatesLocations: locations},
+		}
+	}
+	return artists
+}
+
+func BenchmarkFilterByCountry(b *testing.B) {
+	artists := benchArtists(benchArtistCount)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		filterByCountry(artists, "New Zealand")
+	}
+}
+
+func BenchmarkFuzzySearch(b *testing.B) {
+	artists := benchArtists(benchArtistCount)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		FuzzySearch("velvet stoen", artists, defaultFuzzyThreshold)
+	}
+}
+
+func BenchmarkTopLocations(b *testing.B) {
+	artists := benchArtists(benchArtistCount)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		TopLocations(artists, 10)
+	}
+}
+
+func BenchmarkTemplateRender(b *testing.B) {
+	artists := benchArtists(benchArtistCount)
+	app := newTestApp(b, artists)
+	tmpl := app.template("index")
+	data := IndexPageData{
+		BasePage:     app.basePage(httptest.NewRequest(http.MethodGet, "/", nil), "", ""),
+		PagedArtists: paginate(artists, 1, benchArtistCount),
+		Decades:      AvailableDecades(artists),
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := tmpl.Execute(io.Discard, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Breadcrumb is one link of the navigation trail shown at the top of the pages
+// the current page is the last breadcrumb and has no URL
+type Breadcrumb struct {
+	Label string
+	URL   string
+}
+
+// BasePage holds what every page template needs, it's embedded in the page specific data
+type BasePage struct {
+	Title       string
+	Breadcrumbs []Breadcrumb
+}
+
+// pageLabels are the breadcrumb labels of the pages with a fixed path
+var pageLabels = map[string]string{
+	"/search":          "Search Results",
+	"/advanced-search": "Advanced Search",
+	"/about":           "About",
+	"/readme":          "Readme",
+	"/compare":         "Compare",
+}
+
+// BuildBreadcrumbs returns the breadcrumb trail of the page at path, home first
+// artistName is the label of the artist detail pages and is ignored for the other paths
+func BuildBreadcrumbs(path string, artistName string) []Breadcrumb {
+	if path == "/" {
+		return []Breadcrumb{{Label: "Home"}}
+	}
+
+	home := Breadcrumb{Label: "Home", URL: "/"}
+	if strings.HasPrefix(path, "/artist/") && artistName != "" {
+		return []Breadcrumb{home, {Label: artistName}}
+	}
+	if label, found := pageLabels[path]; found {
+		return []Breadcrumb{home, {Label: label}}
+	}
+	return []Breadcrumb{home}
+}
+
+// basePage returns the BasePage of r, title is prefixed to the app name in the page title
+func (a *App) basePage(r *http.Request, title, artistName string) BasePage {
+	return BasePage{
+		Title:       pageTitle(title, a.Config.withDefaults().AppName),
+		Breadcrumbs: BuildBreadcrumbs(r.URL.Path, artistName),
+	}
+}
+
+// pageTitle returns "title - appName", or only appName when title is empty
+func pageTitle(title, appName string) string {
+	if title == "" {
+		return appName
+	}
+	return title + " - " + appName
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Cache holds a fetched value in memory for the duration of its TTL
+// once the TTL is over the next GetOrFetch call refreshes the value using the provided fetch function
+type Cache[T any] struct {
+	Value     T
+	FetchedAt time.Time
+	TTL       time.Duration
+
+	mu sync.Mutex
+}
+
+// GetOrFetch returns the cached value if it's still fresh, otherwise it calls fetch and caches the result
+// if fetch fails the cache is left untouched and the error is returned
+func (c *Cache[T]) GetOrFetch(fetch func() (T, error)) (T, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.FetchedAt.IsZero() && time.Since(c.FetchedAt) < c.TTL {
+		return c.Value, nil
+	}
+
+	value, err := fetch()
+	if err != nil {
+		return value, err
+	}
+	c.Value = value
+	c.FetchedAt = time.Now()
+	return value, nil
+}
+
+// Cached returns the last fetched value, even past its TTL, and whether a value was ever fetched
+func (c *Cache[T]) Cached() (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Value, !c.FetchedAt.IsZero()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// computeDataETag hashes the artists with SHA-256 and returns it as a quoted ETag
+func computeDataETag(artists []Artists) (string, error) {
+	data, err := json.Marshal(artists)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`, nil
+}
+
+// checkNotModified sets the ETag and Last-Modified headers and answers with 304 when the client's copy is still current
+// it returns true when the 304 was written and the handler must not render the page
+// in dev mode the pages are never cached since the templates can be edited while the data stays the same
+// the JSON version of a page, see negotiateFormat, gets its own ETag since it's a different representation
+func (a *App) checkNotModified(w http.ResponseWriter, r *http.Request) bool {
+	if a.Config.Dev {
+		return false
+	}
+	a.artistsMu.RLock()
+	dataETag, lastModified := a.dataETag, a.dataLastModified.UTC().Truncate(time.Second)
+	a.artistsMu.RUnlock()
+
+	w.Header().Add("Vary", "Accept")
+	w.Header().Add("Vary", "HX-Request")
+	if dataETag != "" && negotiateFormat(r) == "json" {
+		dataETag = strings.TrimSuffix(dataETag, `"`) + `-json"`
+	} else if dataETag != "" && isHTMXRequest(r) {
+		dataETag = strings.TrimSuffix(dataETag, `"`) + `-fragment"`
+	}
+
+	if dataETag != "" {
+		w.Header().Set("ETag", dataETag)
+	}
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+
+	// If-None-Match takes precedence over If-Modified-Since when both are sent
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		if dataETag != "" && etagMatches(match, dataETag) {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+		return false
+	}
+
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !lastModified.IsZero() {
+		if !lastModified.After(since) {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// etagMatches reports whether the If-None-Match header value matches etag, weak validators are compared weakly
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
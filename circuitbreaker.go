@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of calling the API while the circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreaker
+type CircuitState int
+
+// the circuit breaker states, calls are let through while closed, rejected while open
+// and once the recovery timeout is over a single probe call is let through while half open
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+// String returns the name of the state as shown by /api/circuit-status
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker stops calling a failing service for a while so callers fail fast instead of waiting on timeouts
+// it opens after threshold failures in a row and lets one probe through once recoveryTimeout has passed
+type CircuitBreaker struct {
+	threshold       int
+	recoveryTimeout time.Duration
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker returns a closed circuit breaker
+func NewCircuitBreaker(threshold int, recoveryTimeout time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{threshold: threshold, recoveryTimeout: recoveryTimeout}
+}
+
+// the settings of the breaker guarding the calls to the Groupie Trackers API, see newArtistLoader
+const (
+	apiBreakerThreshold = 5
+	apiBreakerRecovery  = 30 * time.Second
+)
+
+// Do calls fn unless the breaker is open, in which case ErrCircuitOpen is returned right away
+// the result of fn is recorded to decide whether the breaker should open or close
+func (cb *CircuitBreaker) Do(fn func() error) error {
+	probe, err := cb.allow()
+	if err != nil {
+		return err
+	}
+	err = fn()
+	cb.record(probe, err)
+	return err
+}
+
+// isAPIFailure reports whether err says the API itself is failing, a network error, a timeout or a 5xx
+// a 4xx or a body that can't be decoded still means the API answered
+func isAPIFailure(err error) bool {
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.Code >= http.StatusInternalServerError
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return !errors.As(err, &syntaxErr) && !errors.As(err, &typeErr)
+}
+
+// State returns the current state of the breaker
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// allow reports whether a call can go through, moving an open breaker to half open once the recovery timeout is over
+// probe is true for the single call let through while half open
+func (cb *CircuitBreaker) allow() (probe bool, err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == CircuitOpen && time.Since(cb.openedAt) >= cb.recoveryTimeout {
+		cb.setState(CircuitHalfOpen)
+	}
+	switch cb.state {
+	case CircuitOpen:
+		return false, ErrCircuitOpen
+	case CircuitHalfOpen:
+		// only one probe at a time, the others fail fast until it's done
+		if cb.probing {
+			return false, ErrCircuitOpen
+		}
+		cb.probing = true
+		return true, nil
+	}
+	return false, nil
+}
+
+// record updates the breaker with the result of a call, see isAPIFailure for what counts as a failure
+// only the probe decides whether a half open breaker closes, a call started before the breaker opened is ignored
+// and a cancelled call says nothing about the API so it's ignored too, a cancelled probe lets the next call probe
+func (cb *CircuitBreaker) record(probe bool, err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if probe {
+		cb.probing = false
+	}
+	if errors.Is(err, context.Canceled) || (!probe && cb.state != CircuitClosed) {
+		return
+	}
+	if err == nil || !isAPIFailure(err) {
+		cb.failures = 0
+		cb.setState(CircuitClosed)
+		return
+	}
+
+	cb.failures++
+	if probe || cb.failures >= cb.threshold {
+		cb.openedAt = time.Now()
+		cb.setState(CircuitOpen)
+	}
+}
+
+// setState changes the state and logs the transition, cb.mu must be held
+func (cb *CircuitBreaker) setState(state CircuitState) {
+	if cb.state == state {
+		return
+	}
+	slog.Info("circuit breaker state changed", slog.String("from", cb.state.String()), slog.String("to", state.String()))
+	cb.state = state
+}
+
+// CircuitStatusHandler responds with the state of the API circuit breaker as JSON, it sits behind BasicAuth like the admin routes
+// an App without a breaker never calls the API so it's reported as closed
+func (a *App) CircuitStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	state := CircuitClosed
+	if a.breaker != nil {
+		state = a.breaker.State()
+	}
+	writeEnvelope(w, r, http.StatusOK, state.String(), APIMeta{Total: 1})
+}
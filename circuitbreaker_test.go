@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// failWith returns a call of the breaker failing with err
+func failWith(err error) func() error {
+	return func() error { return err }
+}
+
+func TestCircuitBreakerFailures(t *testing.T) {
+	transport := errors.New("connection refused")
+	tests := []struct {
+		name     string
+		err      error
+		wantOpen bool
+	}{
+		{"transport error", transport, true},
+		{"5xx", &StatusError{Code: 503}, true},
+		{"timeout", fmt.Errorf("fetching: %w", context.DeadlineExceeded), true},
+		{"4xx", fmt.Errorf("no fixture: %w", &StatusError{Code: 404}), false},
+		{"cancelled", context.Canceled, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cb := NewCircuitBreaker(3, time.Minute)
+			for i := 0; i < 3; i++ {
+				cb.Do(failWith(tt.err))
+			}
+			if open := cb.State() == CircuitOpen; open != tt.wantOpen {
+				t.Errorf("open = %v after 3 calls, want %v", open, tt.wantOpen)
+			}
+		})
+	}
+
+	// a 4xx in between means the API answered so it resets the count
+	cb := NewCircuitBreaker(3, time.Minute)
+	cb.Do(failWith(transport))
+	cb.Do(failWith(transport))
+	cb.Do(failWith(&StatusError{Code: 404}))
+	cb.Do(failWith(transport))
+	if cb.State() != CircuitClosed {
+		t.Errorf("state = %s, want closed", cb.State())
+	}
+}
+
+func TestCircuitBreakerProbe(t *testing.T) {
+	cb := NewCircuitBreaker(1, 10*time.Millisecond)
+
+	// a call starts while the breaker is closed and ends after the probe started
+	slowStarted, slowDone := make(chan bool), make(chan bool)
+	go cb.Do(func() error {
+		slowStarted <- true
+		<-slowDone
+		return nil
+	})
+	<-slowStarted
+	cb.Do(failWith(errors.New("connection refused")))
+	if err := cb.Do(failWith(nil)); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Do on an open breaker = %v, want ErrCircuitOpen", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	probeStarted, probeDone := make(chan bool), make(chan bool)
+	go cb.Do(func() error {
+		probeStarted <- true
+		<-probeDone
+		return nil
+	})
+	<-probeStarted
+	if cb.State() != CircuitHalfOpen {
+		t.Fatalf("state = %s during the probe, want half-open", cb.State())
+	}
+
+	// the old call ending neither closes the breaker nor lets a second probe through
+	slowDone <- true
+	time.Sleep(10 * time.Millisecond)
+	if err := cb.Do(failWith(nil)); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Do during the probe = %v, want ErrCircuitOpen", err)
+	}
+	if cb.State() != CircuitHalfOpen {
+		t.Errorf("state = %s once the old call ended, want half-open", cb.State())
+	}
+
+	probeDone <- true
+	time.Sleep(10 * time.Millisecond)
+	if cb.State() != CircuitClosed {
+		t.Errorf("state = %s after a successful probe, want closed", cb.State())
+	}
+}
+
+func TestArtistLoaderBreaker(t *testing.T) {
+	// every loader has its own breaker so one failing API doesn't open the breaker of another
+	failing := newArtistLoader(Config{}.withDefaults(), NewMetrics(), nil, MockFetcher{})
+	for i := 0; i < apiBreakerThreshold; i++ {
+		failing.breaker.Do(failWith(errors.New("connection refused")))
+	}
+	app := newFixtureApp(t, MockFetcher{Fixtures: apiFixtures})
+	if failing.breaker.State() != CircuitOpen || app.breaker.State() != CircuitClosed {
+		t.Errorf("states = %s and %s, want open and closed", failing.breaker.State(), app.breaker.State())
+	}
+	if failing.relations.breaker != failing.breaker {
+		t.Error("the relations don't share the breaker of the artists")
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// apiDateLayout is the YYYY-MM-DD layout of the from and to query parameters of the API
+const apiDateLayout = "2006-01-02"
+
+// ConcertEntry is a single concert of an artist as returned by the JSON API
+type ConcertEntry struct {
+	Location        string    `json:"location"`
+	DisplayLocation string    `json:"displayLocation"`
+	Date            time.Time `json:"date"`
+	DateFormatted   string    `json:"dateFormatted"`
+}
+
+// FlattenConcerts turns the dates/locations map into a list of concerts sorted by date, then by location
+// dates that can't be parsed are skipped
+func FlattenConcerts(r Relations) []ConcertEntry {
+	concerts := []ConcertEntry{}
+	for location, dates := range r.DatesLocations {
+		for _, date := range dates {
+			parsed, err := parseConcertDate(date)
+			if err != nil {
+				continue
+			}
+			concerts = append(concerts, ConcertEntry{
+				Location:        location,
+				DisplayLocation: FormatLocation(location),
+				Date:            parsed,
+				DateFormatted:   FormatDate(parsed),
+			})
+		}
+	}
+	sort.Slice(concerts, func(i, j int) bool {
+		if !concerts[i].Date.Equal(concerts[j].Date) {
+			return concerts[i].Date.Before(concerts[j].Date)
+		}
+		return concerts[i].Location < concerts[j].Location
+	})
+	return concerts
+}
+
+// dateParam reads a YYYY-MM-DD query parameter, returning the zero time when it's absent
+func dateParam(r *http.Request, key string) (time.Time, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(apiDateLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be a YYYY-MM-DD date", key)
+	}
+	return t, nil
+}
+
+// apiArtistConcerts responds with the concerts of artist sorted by date as JSON
+// the from and to query parameters keep the concerts between those two dates inclusive
+func (a *App) apiArtistConcerts(w http.ResponseWriter, r *http.Request, artist Artists) {
+	from, err := dateParam(r, "from")
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	to, err := dateParam(r, "to")
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		writeJSONError(w, r, http.StatusBadRequest, "from must not be after to")
+		return
+	}
+
+	artist = a.withRelations(r.Context(), artist)
+	concerts := []ConcertEntry{}
+	for _, concert := range FlattenConcerts(artist.DatesLocations) {
+		if (!from.IsZero() && concert.Date.Before(from)) || (!to.IsZero() && concert.Date.After(to)) {
+			continue
+		}
+		concerts = append(concerts, concert)
+	}
+	writeEnvelope(w, r, http.StatusOK, concerts, APIMeta{Total: len(concerts)})
+}
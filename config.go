@@ -0,0 +1,457 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// default values used for every Config field left empty, so the zero-value Config is a usable configuration
+const (
+	defaultAPIBaseURL  = "https://groupietrackers.herokuapp.com/api"
+	defaultPort        = "8080"
+	defaultTemplateDir = "templates"
+	defaultStaticDir   = "templates"
+	defaultGenresFile  = "genres.json"
+	defaultSLOFile     = "slo.json"
+	defaultLocalFile   = "local_artists.json"
+	defaultTLSCacheDir = "certs"
+	defaultAppName     = "Groupie Tracker"
+	defaultShortName   = "Groupie"
+	defaultBackground  = "#131212"
+	defaultThemeColor  = "#F4B011"
+	defaultCacheTTL    = 5 * time.Minute
+	defaultRefresh     = 15 * time.Minute
+	defaultRateLimit   = 10
+	defaultRateBurst   = 20
+
+	defaultMaxIdleConnsPerHost   = 10
+	defaultIdleConnTimeout       = 90 * time.Second
+	defaultDialTimeout           = 5 * time.Second
+	defaultTLSHandshakeTimeout   = 5 * time.Second
+	defaultResponseHeaderTimeout = 10 * time.Second
+)
+
+// defaultCSP allows our own resources plus the google fonts and the artist images served by the API
+const defaultCSP = "default-src 'self'; " +
+	"style-src 'self' 'unsafe-inline' https://fonts.googleapis.com; " +
+	"font-src 'self' https://fonts.gstatic.com; " +
+	"img-src 'self' data: https:; " +
+	"script-src 'self' 'unsafe-inline'"
+
+// Duration is a time.Duration that reads and writes itself in JSON as a string like "5m" or "30s"
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string such as "5m"
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"5m\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalJSON writes the duration as a string such as "5m0s"
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Config holds the server settings
+// LoadConfig sets them, highest priority first, from the command-line flags, the JSON config file,
+// the environment variables named below and the defaults
+type Config struct {
+	// APIBaseURL is the base URL of the Groupie Trackers API, read from APP_API_BASE_URL
+	APIBaseURL string `json:"apiBaseURL"`
+	// Host is the interface the server listens on, empty means all of them, read from APP_HOST or HOST
+	Host string `json:"host"`
+	// PublicURL is the URL the site is reachable at, used for the absolute links of the sitemap, read from APP_PUBLIC_URL
+	// it defaults to http://localhost:<port>
+	PublicURL string `json:"publicURL"`
+	// Port is the port the server listens on, read from APP_PORT or PORT
+	Port string `json:"port"`
+	// TemplateDir is the embedded directory holding the html templates, read from APP_TEMPLATE_DIR or TEMPLATE_DIR
+	TemplateDir string `json:"templateDir"`
+	// StaticDir is the embedded directory the /static/ and /assets/ files are served from, read from APP_STATIC_DIR or STATIC_DIR
+	StaticDir string `json:"staticDir"`
+	// GenresFile is the JSON file mapping artist ids to genres, read from APP_GENRES_FILE
+	GenresFile string `json:"genresFile"`
+	// LocalArtistsFile is the JSON file of the artists added to or replacing the ones of the API, read from APP_LOCAL_ARTISTS_FILE
+	LocalArtistsFile string `json:"localArtistsFile"`
+	// SLOFile is the JSON file listing the latency SLO of the routes, read from APP_SLO_FILE
+	SLOFile string `json:"sloFile"`
+	// AllowedOrigins lists the origins allowed to call the JSON API, "*" allows any origin
+	// read from the comma-separated APP_ALLOWED_ORIGINS or ALLOWED_ORIGINS
+	AllowedOrigins []string `json:"allowedOrigins"`
+	// RobotsDisallow lists the paths crawlers are asked not to visit in robots.txt
+	// read from the comma-separated APP_ROBOTS_DISALLOW or ROBOTS_DISALLOW
+	RobotsDisallow []string `json:"robotsDisallow"`
+	// RestrictedPaths lists the directory paths answered with a 403 instead of a listing, with or without a trailing slash
+	// read from the comma-separated APP_RESTRICTED_PATHS
+	RestrictedPaths []string `json:"restrictedPaths"`
+	// ImageProxyHosts lists the hosts /proxy/image is allowed to fetch images from
+	// read from the comma-separated APP_IMAGE_PROXY_HOSTS
+	ImageProxyHosts []string `json:"imageProxyHosts"`
+	// CSP is the Content-Security-Policy header value, read from APP_CSP or CSP_HEADER
+	CSP string `json:"csp"`
+	// Dev turns off the security headers for local development, read from APP_DEV or DEV
+	Dev bool `json:"dev"`
+	// LogLevel is the lowest level logged, debug, info, warn or error, read from APP_LOG_LEVEL
+	LogLevel string `json:"logLevel"`
+	// CacheTTL is how long the API responses are cached, e.g. "5m", read from APP_CACHE_TTL or CACHE_TTL
+	CacheTTL Duration `json:"cacheTTL"`
+	// RefreshInterval is how often the artist data is fetched again in the background, e.g. "15m", read from APP_REFRESH_INTERVAL
+	RefreshInterval Duration `json:"refreshInterval"`
+	// RateLimit is the number of requests per second allowed per IP, read from APP_RATE_LIMIT
+	RateLimit float64 `json:"rateLimit"`
+	// RateBurst is the number of requests an IP can make in a burst, read from APP_RATE_BURST
+	RateBurst int `json:"rateBurst"`
+	// PageCacheSize is the number of rendered pages kept in memory, read from APP_PAGE_CACHE_SIZE
+	// the cache is off in dev mode
+	PageCacheSize int `json:"pageCacheSize"`
+	// AppName, AppShortName, BackgroundColor and ThemeColor are the name and colors of the web app manifest
+	// read from APP_NAME, APP_SHORT_NAME, APP_BACKGROUND_COLOR and APP_THEME_COLOR
+	AppName         string `json:"appName"`
+	AppShortName    string `json:"appShortName"`
+	BackgroundColor string `json:"backgroundColor"`
+	ThemeColor      string `json:"themeColor"`
+	// AdminUser and AdminPassword are the HTTP Basic Auth credentials of the /admin/ endpoints, read from APP_ADMIN_USER and APP_ADMIN_PASSWORD
+	// the admin endpoints refuse every request while either of them is empty
+	AdminUser     string `json:"adminUser"`
+	AdminPassword string `json:"adminPassword"`
+	// TLSCertFile is the certificate file of HTTPS, "auto" gets certificates from Let's Encrypt, read from APP_TLS_CERT_FILE
+	// HTTPS is served when it's "auto" or when TLSKeyFile is set too, plain HTTP on port 80 is then redirected to it
+	TLSCertFile string `json:"tlsCertFile"`
+	// TLSKeyFile is the private key file of TLSCertFile, read from APP_TLS_KEY_FILE
+	TLSKeyFile string `json:"tlsKeyFile"`
+	// TLSCacheDir is the directory the Let's Encrypt certificates are cached in, read from APP_TLS_CACHE_DIR
+	TLSCacheDir string `json:"tlsCacheDir"`
+	// HTTPMaxIdleConnsPerHost is the number of idle connections to the API kept open, read from APP_HTTP_MAX_IDLE_CONNS_PER_HOST
+	HTTPMaxIdleConnsPerHost int `json:"httpMaxIdleConnsPerHost"`
+	// HTTPIdleConnTimeout is how long an idle connection to the API is kept open, read from APP_HTTP_IDLE_CONN_TIMEOUT
+	HTTPIdleConnTimeout Duration `json:"httpIdleConnTimeout"`
+	// HTTPDialTimeout, HTTPTLSHandshakeTimeout and HTTPResponseHeaderTimeout bound the steps of a request to the API
+	// read from APP_HTTP_DIAL_TIMEOUT, APP_HTTP_TLS_HANDSHAKE_TIMEOUT and APP_HTTP_RESPONSE_HEADER_TIMEOUT
+	HTTPDialTimeout           Duration `json:"httpDialTimeout"`
+	HTTPTLSHandshakeTimeout   Duration `json:"httpTLSHandshakeTimeout"`
+	HTTPResponseHeaderTimeout Duration `json:"httpResponseHeaderTimeout"`
+}
+
+// LoadConfig builds the config from, lowest priority first, the defaults, the environment variables,
+// the JSON config file at path and the command-line flags, flags can be nil when there are none
+// a missing file isn't an error, the environment and the defaults are used instead
+func LoadConfig(path string, flags *Flags) (*Config, error) {
+	cfg := &Config{}
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	// fields missing from the file keep the value of the environment
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	default:
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("error parsing config file %s: %w", path, err)
+		}
+	}
+
+	if flags != nil {
+		flags.apply(cfg)
+	}
+	cfg.applyDefaults()
+	if _, err := parseLogLevel(cfg.LogLevel); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// parseLogLevel returns the slog level named by level, debug, info, warn or error case insensitive, empty means info
+func parseLogLevel(level string) (slog.Level, error) {
+	var parsed slog.Level
+	if level == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := parsed.UnmarshalText([]byte(level)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q, must be debug, info, warn or error", level)
+	}
+	return parsed, nil
+}
+
+// applyEnv sets the fields that have a matching environment variable, the config file and the flags are applied after it
+func (c *Config) applyEnv() error {
+	if value, ok := lookupEnv("APP_API_BASE_URL"); ok {
+		c.APIBaseURL = value
+	}
+	if value, ok := lookupEnv("APP_HOST", "HOST"); ok {
+		c.Host = value
+	}
+	if value, ok := lookupEnv("APP_PORT", "PORT"); ok {
+		c.Port = value
+	}
+	if value, ok := lookupEnv("APP_PUBLIC_URL"); ok {
+		c.PublicURL = value
+	}
+	if value, ok := lookupEnv("APP_TEMPLATE_DIR", "TEMPLATE_DIR"); ok {
+		c.TemplateDir = value
+	}
+	if value, ok := lookupEnv("APP_STATIC_DIR", "STATIC_DIR"); ok {
+		c.StaticDir = value
+	}
+	if value, ok := lookupEnv("APP_GENRES_FILE"); ok {
+		c.GenresFile = value
+	}
+	if value, ok := lookupEnv("APP_LOCAL_ARTISTS_FILE"); ok {
+		c.LocalArtistsFile = value
+	}
+	if value, ok := lookupEnv("APP_SLO_FILE"); ok {
+		c.SLOFile = value
+	}
+	if value, ok := lookupEnv("APP_ALLOWED_ORIGINS", "ALLOWED_ORIGINS"); ok {
+		c.AllowedOrigins = splitList(value)
+	}
+	if value, ok := lookupEnv("APP_ROBOTS_DISALLOW", "ROBOTS_DISALLOW"); ok {
+		c.RobotsDisallow = splitList(value)
+	}
+	if value, ok := lookupEnv("APP_RESTRICTED_PATHS"); ok {
+		c.RestrictedPaths = splitList(value)
+	}
+	if value, ok := lookupEnv("APP_IMAGE_PROXY_HOSTS"); ok {
+		c.ImageProxyHosts = splitList(value)
+	}
+	if value, ok := lookupEnv("APP_CSP", "CSP_HEADER"); ok {
+		c.CSP = value
+	}
+	if value, ok := lookupEnv("APP_DEV", "DEV"); ok {
+		c.Dev = value == "true"
+	}
+	if value, ok := lookupEnv("APP_LOG_LEVEL"); ok {
+		c.LogLevel = value
+	}
+	if value, ok := lookupEnv("APP_CACHE_TTL", "CACHE_TTL"); ok {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid cache TTL %q: %w", value, err)
+		}
+		c.CacheTTL = Duration(ttl)
+	}
+	if value, ok := lookupEnv("APP_REFRESH_INTERVAL"); ok {
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid refresh interval %q: %w", value, err)
+		}
+		c.RefreshInterval = Duration(interval)
+	}
+	if value, ok := lookupEnv("APP_RATE_LIMIT"); ok {
+		limit, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid rate limit %q: %w", value, err)
+		}
+		c.RateLimit = limit
+	}
+	if value, ok := lookupEnv("APP_RATE_BURST"); ok {
+		burst, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid rate burst %q: %w", value, err)
+		}
+		c.RateBurst = burst
+	}
+	if value, ok := lookupEnv("APP_PAGE_CACHE_SIZE"); ok {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid page cache size %q: %w", value, err)
+		}
+		c.PageCacheSize = size
+	}
+	if value, ok := lookupEnv("APP_NAME"); ok {
+		c.AppName = value
+	}
+	if value, ok := lookupEnv("APP_SHORT_NAME"); ok {
+		c.AppShortName = value
+	}
+	if value, ok := lookupEnv("APP_BACKGROUND_COLOR"); ok {
+		c.BackgroundColor = value
+	}
+	if value, ok := lookupEnv("APP_THEME_COLOR"); ok {
+		c.ThemeColor = value
+	}
+	if value, ok := lookupEnv("APP_ADMIN_USER"); ok {
+		c.AdminUser = value
+	}
+	if value, ok := lookupEnv("APP_ADMIN_PASSWORD"); ok {
+		c.AdminPassword = value
+	}
+	if value, ok := lookupEnv("APP_TLS_CERT_FILE"); ok {
+		c.TLSCertFile = value
+	}
+	if value, ok := lookupEnv("APP_TLS_KEY_FILE"); ok {
+		c.TLSKeyFile = value
+	}
+	if value, ok := lookupEnv("APP_TLS_CACHE_DIR"); ok {
+		c.TLSCacheDir = value
+	}
+	if value, ok := lookupEnv("APP_HTTP_MAX_IDLE_CONNS_PER_HOST"); ok {
+		conns, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid max idle connections %q: %w", value, err)
+		}
+		c.HTTPMaxIdleConnsPerHost = conns
+	}
+	timeouts := []struct {
+		env    string
+		target *Duration
+	}{
+		{"APP_HTTP_IDLE_CONN_TIMEOUT", &c.HTTPIdleConnTimeout},
+		{"APP_HTTP_DIAL_TIMEOUT", &c.HTTPDialTimeout},
+		{"APP_HTTP_TLS_HANDSHAKE_TIMEOUT", &c.HTTPTLSHandshakeTimeout},
+		{"APP_HTTP_RESPONSE_HEADER_TIMEOUT", &c.HTTPResponseHeaderTimeout},
+	}
+	for _, timeout := range timeouts {
+		if value, ok := lookupEnv(timeout.env); ok {
+			parsed, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s %q: %w", timeout.env, value, err)
+			}
+			*timeout.target = Duration(parsed)
+		}
+	}
+	return nil
+}
+
+// applyDefaults fills every empty field with its default value
+func (c *Config) applyDefaults() {
+	if c.APIBaseURL == "" {
+		c.APIBaseURL = defaultAPIBaseURL
+	}
+	if c.Port == "" {
+		c.Port = defaultPort
+	}
+	if c.PublicURL == "" {
+		c.PublicURL = "http://localhost:" + c.Port
+	}
+	if c.TemplateDir == "" {
+		c.TemplateDir = defaultTemplateDir
+	}
+	if c.StaticDir == "" {
+		c.StaticDir = defaultStaticDir
+	}
+	if c.GenresFile == "" {
+		c.GenresFile = defaultGenresFile
+	}
+	if c.LocalArtistsFile == "" {
+		c.LocalArtistsFile = defaultLocalFile
+	}
+	if c.SLOFile == "" {
+		c.SLOFile = defaultSLOFile
+	}
+	if c.RobotsDisallow == nil {
+		c.RobotsDisallow = []string{"/admin/", "/api/"}
+	}
+	if c.RestrictedPaths == nil {
+		c.RestrictedPaths = []string{"/static", "/assets", "/static/assets"}
+	}
+	if c.ImageProxyHosts == nil {
+		c.ImageProxyHosts = []string{"groupietrackers.herokuapp.com"}
+	}
+	if c.CSP == "" {
+		c.CSP = defaultCSP
+	}
+	if c.CacheTTL <= 0 {
+		c.CacheTTL = Duration(defaultCacheTTL)
+	}
+	if c.RefreshInterval <= 0 {
+		c.RefreshInterval = Duration(defaultRefresh)
+	}
+	if c.RateLimit <= 0 {
+		c.RateLimit = defaultRateLimit
+	}
+	if c.RateBurst <= 0 {
+		c.RateBurst = defaultRateBurst
+	}
+	if c.PageCacheSize <= 0 {
+		c.PageCacheSize = defaultPageCacheSize
+	}
+	if c.AppName == "" {
+		c.AppName = defaultAppName
+	}
+	if c.AppShortName == "" {
+		c.AppShortName = defaultShortName
+	}
+	if c.BackgroundColor == "" {
+		c.BackgroundColor = defaultBackground
+	}
+	if c.ThemeColor == "" {
+		c.ThemeColor = defaultThemeColor
+	}
+	if c.TLSCacheDir == "" {
+		c.TLSCacheDir = defaultTLSCacheDir
+	}
+	if c.HTTPMaxIdleConnsPerHost <= 0 {
+		c.HTTPMaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+	if c.HTTPIdleConnTimeout <= 0 {
+		c.HTTPIdleConnTimeout = Duration(defaultIdleConnTimeout)
+	}
+	if c.HTTPDialTimeout <= 0 {
+		c.HTTPDialTimeout = Duration(defaultDialTimeout)
+	}
+	if c.HTTPTLSHandshakeTimeout <= 0 {
+		c.HTTPTLSHandshakeTimeout = Duration(defaultTLSHandshakeTimeout)
+	}
+	if c.HTTPResponseHeaderTimeout <= 0 {
+		c.HTTPResponseHeaderTimeout = Duration(defaultResponseHeaderTimeout)
+	}
+}
+
+// withDefaults returns a copy of the config with every empty field set to its default
+// it's what makes the zero-value Config usable
+func (c Config) withDefaults() Config {
+	c.applyDefaults()
+	return c
+}
+
+// Addr returns the address the server listens on, e.g. ":8080" or "127.0.0.1:8080"
+func (c Config) Addr() string {
+	return net.JoinHostPort(c.Host, c.withDefaults().Port)
+}
+
+// lookupEnv returns the value of the first of the environment variables that is set and not empty
+func lookupEnv(keys ...string) (string, bool) {
+	for _, key := range keys {
+		if value := os.Getenv(key); value != "" {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// getEnv returns the value of the environment variable key, or fallback if it is unset or empty
+func getEnv(key, fallback string) string {
+	if value, ok := lookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+// splitList splits a comma-separated list, trimming spaces and dropping empty entries
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+// date layouts, the DD-MM-YYYY format the Groupie Trackers API uses for concert dates and the one dates are displayed in
+const (
+	concertDateLayout = "02-01-2006"
+	displayDateLayout = "January 2, 2006"
+	yearLayout        = "2006"
+)
+
+// parseConcertDate parses a DD-MM-YYYY concert date, the API sometimes prefixes dates with a "*" which is ignored
+func parseConcertDate(s string) (time.Time, error) {
+	return time.Parse(concertDateLayout, strings.TrimPrefix(strings.TrimSpace(s), "*"))
+}
+
+// SortedDates parses DD-MM-YYYY dates and returns them sorted in calendar order
+// an error is returned for the first date that can't be parsed
+func SortedDates(dates []string) ([]time.Time, error) {
+	parsed := make([]time.Time, 0, len(dates))
+	for _, date := range dates {
+		t, err := parseConcertDate(date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid concert date %q: %w", date, err)
+		}
+		parsed = append(parsed, t)
+	}
+	sort.Slice(parsed, func(i, j int) bool {
+		return parsed[i].Before(parsed[j])
+	})
+	return parsed, nil
+}
+
+// sortedDatesOrSkip is the sortedDates of the templates, an error there would abort the whole page
+// so the dates that can't be parsed are logged and left out instead
+func sortedDatesOrSkip(dates []string) []time.Time {
+	parsed := make([]time.Time, 0, len(dates))
+	for _, date := range dates {
+		t, err := parseConcertDate(date)
+		if err != nil {
+			slog.Warn("skipping invalid concert date", slog.String("date", date), slog.Any("error", err))
+			continue
+		}
+		parsed = append(parsed, t)
+	}
+	sort.Slice(parsed, func(i, j int) bool {
+		return parsed[i].Before(parsed[j])
+	})
+	return parsed
+}
+
+// FormatDate formats a date for display, e.g. "January 2, 2006"
+func FormatDate(t time.Time) string {
+	return t.Format(displayDateLayout)
+}
+
+// parseFirstAlbum parses a first album date, either DD-MM-YYYY or just YYYY
+// unrecognised formats give the zero time
+func parseFirstAlbum(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{concertDateLayout, yearLayout} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// year returns the year of t, used by the templates
+func year(t time.Time) int {
+	return t.Year()
+}
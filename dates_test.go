@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFirstAlbum(t *testing.T) {
+	tests := []struct {
+		s    string
+		want time.Time
+	}{
+		{"14-12-1973", time.Date(1973, time.December, 14, 0, 0, 0, 0, time.UTC)},
+		{" 05-08-1967 ", time.Date(1967, time.August, 5, 0, 0, 0, 0, time.UTC)},
+		{"1997", time.Date(1997, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"", time.Time{}},
+		{"1973-12-14", time.Time{}},
+		{"32-01-2000", time.Time{}},
+		{"soon", time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := parseFirstAlbum(tt.s); !got.Equal(tt.want) {
+			t.Errorf("parseFirstAlbum(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestYear(t *testing.T) {
+	if got := year(parseFirstAlbum("14-12-1973")); got != 1973 {
+		t.Errorf("year = %d, want 1973", got)
+	}
+	if got := year(parseFirstAlbum("1997")); got != 1997 {
+		t.Errorf("year = %d, want 1997", got)
+	}
+}
+
+func TestSortedDates(t *testing.T) {
+	got, err := SortedDates([]string{"03-01-2020", "*01-02-2019", "02-01-2020"})
+	if err != nil {
+		t.Fatalf("SortedDates: %v", err)
+	}
+	want := []string{"01-02-2019", "02-01-2020", "03-01-2020"}
+	for i, date := range got {
+		if date.Format(concertDateLayout) != want[i] {
+			t.Errorf("date %d = %s, want %s", i, date.Format(concertDateLayout), want[i])
+		}
+	}
+	if _, err := SortedDates([]string{"01-01-2020", "2020-01-02"}); err == nil {
+		t.Error("SortedDates with a malformed date = nil error, want an error")
+	}
+}
+
+func TestSortedDatesOrSkip(t *testing.T) {
+	got := sortedDatesOrSkip([]string{"03-01-2020", "soon", "02-01-2020"})
+	if len(got) != 2 || got[0].Format(concertDateLayout) != "02-01-2020" || got[1].Format(concertDateLayout) != "03-01-2020" {
+		t.Errorf("sortedDatesOrSkip = %v, want the two valid dates in order", got)
+	}
+}
+
+func TestIndexWithInvalidDate(t *testing.T) {
+	// a bad date of one artist mustn't turn the whole page into a 500
+	artists := testArtists()
+	artists[0].DatesLocations.DatesLocations["london-uk"] = []string{"01-01-2020", "not a date"}
+	handler := newTestServer(t, newTestApp(t, artists))
+
+	w := serve(handler, http.MethodGet, "/", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET / = %d, want %d", w.Code, http.StatusOK)
+	}
+	if !strings.Contains(w.Body.String(), "January 1, 2020") {
+		t.Error("the valid date of Queen isn't shown")
+	}
+	if strings.Contains(w.Body.String(), "not a date") {
+		t.Error("the invalid date is shown")
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"log/slog"
+	"net/http"
+)
+
+// AppError is an error answered with an HTTP status and a message that is safe to show to the user
+// Internal keeps the underlying cause for the logs, it's never sent in the response
+type AppError struct {
+	HTTPCode int
+	Message  string
+	Internal error
+}
+
+// Error returns the message, followed by the internal error when there is one
+func (e *AppError) Error() string {
+	if e.Internal != nil {
+		return e.Message + ": " + e.Internal.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the internal error so errors.Is and errors.As see through it
+func (e *AppError) Unwrap() error {
+	return e.Internal
+}
+
+// NewAppError returns an AppError with the given status and message
+func NewAppError(code int, message string) *AppError {
+	return &AppError{HTTPCode: code, Message: message}
+}
+
+// BadRequestError returns a 400 AppError, message usually explains which parameter is wrong
+func BadRequestError(message string) *AppError {
+	return NewAppError(http.StatusBadRequest, message)
+}
+
+// ForbiddenError returns a 403 AppError
+func ForbiddenError(message string) *AppError {
+	return NewAppError(http.StatusForbidden, message)
+}
+
+// NotFoundError returns a 404 AppError
+func NotFoundError(message string) *AppError {
+	return NewAppError(http.StatusNotFound, message)
+}
+
+// MethodNotAllowedError returns a 405 AppError
+func MethodNotAllowedError() *AppError {
+	return NewAppError(http.StatusMethodNotAllowed, "Method not allowed")
+}
+
+// TooManyRequestsError returns a 429 AppError
+func TooManyRequestsError() *AppError {
+	return NewAppError(http.StatusTooManyRequests, "Too many requests")
+}
+
+// InternalError returns a 500 AppError wrapping err, the user only sees a generic message
+func InternalError(err error) *AppError {
+	return &AppError{HTTPCode: http.StatusInternalServerError, Message: "Internal server error", Internal: err}
+}
+
+// WriteError renders the error page for ae with its status code
+// the error is logged along with the id of the request found in ctx
+func WriteError(ctx context.Context, w http.ResponseWriter, ae *AppError, tmpl *template.Template) {
+	attrs := []any{slog.Int("status", ae.HTTPCode), slog.String("message", ae.Message)}
+	if ae.Internal != nil {
+		attrs = append(attrs, slog.Any("error", ae.Internal))
+	}
+	slog.WarnContext(ctx, "request failed", attrs...)
+
+	code := ae.HTTPCode
+	errorPage := ErrorPage{
+		BasePage: BasePage{
+			Title:       fmt.Sprintf("Error %d", code),
+			Breadcrumbs: BuildBreadcrumbs("", ""),
+		},
+		Code:    code,
+		Message: ae.Message,
+		Is405:   code == http.StatusMethodNotAllowed,
+		Is404:   code == http.StatusNotFound,
+		Is500:   code == http.StatusInternalServerError,
+		Is403:   code == http.StatusForbidden,
+		Is429:   code == http.StatusTooManyRequests,
+	}
+	w.WriteHeader(code)
+	if err := tmpl.Execute(w, errorPage); err != nil {
+		log.Printf("Error executing error template: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
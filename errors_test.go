@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAppErrorConstructors(t *testing.T) {
+	cause := errors.New("template exploded")
+	tests := []struct {
+		name        string
+		err         *AppError
+		wantCode    int
+		wantMessage string
+	}{
+		{"bad request", BadRequestError("page must be a number"), http.StatusBadRequest, "page must be a number"},
+		{"forbidden", ForbiddenError("Access Denied"), http.StatusForbidden, "Access Denied"},
+		{"not found", NotFoundError("Page not found"), http.StatusNotFound, "Page not found"},
+		{"method not allowed", MethodNotAllowedError(), http.StatusMethodNotAllowed, "Method not allowed"},
+		{"too many requests", TooManyRequestsError(), http.StatusTooManyRequests, "Too many requests"},
+		{"internal", InternalError(cause), http.StatusInternalServerError, "Internal server error"},
+		{"custom", NewAppError(http.StatusTeapot, "Short and stout"), http.StatusTeapot, "Short and stout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.err.HTTPCode != tt.wantCode || tt.err.Message != tt.wantMessage {
+				t.Errorf("got %d %q, want %d %q", tt.err.HTTPCode, tt.err.Message, tt.wantCode, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestAppErrorInternal(t *testing.T) {
+	cause := errors.New("template exploded")
+	err := InternalError(cause)
+	if !errors.Is(err, cause) {
+		t.Error("errors.Is doesn't see the internal error")
+	}
+	if err.Error() != "Internal server error: template exploded" {
+		t.Errorf("Error = %q", err.Error())
+	}
+	if got := NotFoundError("Page not found").Error(); got != "Page not found" {
+		t.Errorf("Error = %q, want the message alone", got)
+	}
+
+	var appErr *AppError
+	if !errors.As(error(err), &appErr) || appErr.HTTPCode != http.StatusInternalServerError {
+		t.Error("errors.As doesn't find the AppError")
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	tmpl := template.Must(template.New("error").Parse(`{{.Code}} {{.Message}} {{if .Is404}}not found{{end}}`))
+
+	w := httptest.NewRecorder()
+	WriteError(context.Background(), w, NotFoundError("Page not found"), tmpl)
+	if w.Code != http.StatusNotFound || w.Body.String() != "404 Page not found not found" {
+		t.Errorf("got %d %q", w.Code, w.Body)
+	}
+
+	// the internal error stays in the logs
+	w = httptest.NewRecorder()
+	WriteError(context.Background(), w, InternalError(errors.New("secret database password")), tmpl)
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "secret") {
+		t.Errorf("got %d %q", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// sseRetryMillis is the reconnection delay the /events/artists clients are told to wait
+const sseRetryMillis = 5000
+
+// maxEventClients is the number of clients that can listen to /events/artists at the same time, like maxWSClients
+const maxEventClients = 100
+
+// artistsUpdate is the data of the update events of /events/artists
+type artistsUpdate struct {
+	ArtistCount int    `json:"artistCount"`
+	Version     string `json:"version"`
+}
+
+// notifyEventClients sends an update event to every /events/artists client
+// a client that hasn't read the previous event yet only gets the latest one
+func (a *App) notifyEventClients(count int, etag string) {
+	data, _ := json.Marshal(artistsUpdate{ArtistCount: count, Version: strings.Trim(etag, `"`)})
+	event := []byte("event: update\ndata: " + string(data) + "\n\n")
+	a.eventClients.Range(func(key, _ any) bool {
+		events := key.(chan []byte)
+		select {
+		case <-events:
+		default:
+		}
+		select {
+		case events <- event:
+		default:
+		}
+		return true
+	})
+}
+
+// EventsHandler streams an update server-sent event to the client every time the artist data is refreshed
+// it's a lighter alternative to /ws/refresh, the stream ends when the client goes away
+// extra clients past maxEventClients get a 503
+func (a *App) EventsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/events/artists" {
+		WriteError(r.Context(), w, NotFoundError("Page not found"), a.template("error"))
+		return
+	}
+	if r.Method != http.MethodGet {
+		WriteError(r.Context(), w, MethodNotAllowedError(), a.template("error"))
+		return
+	}
+
+	if a.eventClientCount.Add(1) > maxEventClients {
+		a.eventClientCount.Add(-1)
+		WriteError(r.Context(), w, NewAppError(http.StatusServiceUnavailable, "Too many live connections, try again later"), a.template("error"))
+		return
+	}
+	defer a.eventClientCount.Add(-1)
+
+	flusher := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetryMillis)
+	if err := flusher.Flush(); err != nil {
+		return
+	}
+
+	// the channel itself is the key, every client gets its own whatever the headers it sent
+	events := make(chan []byte, 1)
+	a.eventClients.Store(events, struct{}{})
+	defer a.eventClients.Delete(events)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-events:
+			if _, err := w.Write(event); err != nil {
+				return
+			}
+			if err := flusher.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
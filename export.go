@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/csv"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// csvHeader is the first row of the CSV export
+var csvHeader = []string{"id", "name", "creationDate", "firstAlbum", "members", "concertCount", "uniqueLocations"}
+
+// ExportCSVHandler downloads the artists as a CSV file, with the same filter, sort and pagination parameters as /api/artists
+// the members are joined with "|" so every artist stays on a single row
+func (a *App) ExportCSVHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(r.Context(), w, MethodNotAllowedError(), a.template("error"))
+		return
+	}
+
+	listed, err := applyListingQuery(a.artists(), r.URL.Query())
+	if err != nil {
+		WriteError(r.Context(), w, BadRequestError(err.Error()), a.template("error"))
+		return
+	}
+	page, limit := paginationParams(r)
+	paged := paginate(listed, page, limit)
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="artists.csv"`)
+
+	writer := csv.NewWriter(w)
+	writer.Write(csvHeader)
+	for _, artist := range paged.Artists {
+		writer.Write([]string{
+			strconv.Itoa(artist.ID),
+			csvSafe(artist.Name),
+			strconv.Itoa(artist.CreationDate),
+			csvSafe(artist.FirstAlbum),
+			csvSafe(strings.Join(artist.Members, "|")),
+			strconv.Itoa(artist.TotalConcerts()),
+			strconv.Itoa(artist.UniqueLocations()),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		// the headers are already sent, all that's left is to log it
+		slog.ErrorContext(r.Context(), "error writing CSV export", slog.Any("error", err))
+	}
+}
+
+// csvSafe prefixes the values spreadsheets would run as a formula, the ones starting with =, +, - or @, with a quote
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
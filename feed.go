@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RSSFeed is the root rss element of an RSS 2.0 feed
+type RSSFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel RSSChannel `xml:"channel"`
+}
+
+// RSSChannel describes the feed and holds its items
+type RSSChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Items       []RSSItem `xml:"item"`
+}
+
+// RSSItem is a single artist of the feed
+type RSSItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+}
+
+// buildFeed lists every artist as an item, the server start time stands in for when they were added
+func (a *App) buildFeed() ([]byte, error) {
+	cfg := a.Config.withDefaults()
+	base := strings.TrimSuffix(cfg.PublicURL, "/")
+	pubDate := a.StartTime.UTC().Format(time.RFC1123Z)
+
+	feed := RSSFeed{
+		Version: "2.0",
+		Channel: RSSChannel{
+			Title:       cfg.AppName,
+			Link:        base + "/",
+			Description: "The artists tracked by " + cfg.AppName,
+		},
+	}
+	for _, artist := range a.artists() {
+		link := base + "/artist/" + strconv.Itoa(artist.ID)
+		feed.Channel.Items = append(feed.Channel.Items, RSSItem{
+			Title:       artist.Name,
+			Link:        link,
+			GUID:        link,
+			Description: "Members: " + joinComma(artist.Members) + ". Active since " + strconv.Itoa(artist.CreationDate),
+			PubDate:     pubDate,
+		})
+	}
+
+	body, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// FeedHandler serves the RSS feed, it's built on the first request and cached until the artists change
+func (a *App) FeedHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	a.feedMu.Lock()
+	if a.feed == nil {
+		feed, err := a.buildFeed()
+		if err != nil {
+			a.feedMu.Unlock()
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		a.feed = feed
+	}
+	feed := a.feed
+	a.feedMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/rss+xml")
+	w.Write(feed)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Fetcher gets the JSON document at url and decodes it into target
+// the loader and the relation cache only talk to the API through it so they can run on fixtures
+type Fetcher interface {
+	Fetch(ctx context.Context, url string, target interface{}) error
+}
+
+// StatusError is the error of an API response with a status other than 200
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("received non-200 response code: %d", e.Code)
+}
+
+// HTTPFetcher fetches with an HTTP GET request bound to ctx, Client defaults to defaultHTTPClient
+type HTTPFetcher struct {
+	Client *http.Client
+}
+
+// Fetch makes the GET request and decodes the JSON response, any status other than 200 is an error
+func (f HTTPFetcher) Fetch(ctx context.Context, url string, target interface{}) error {
+	client := f.Client
+	if client == nil {
+		client = defaultHTTPClient
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("error creating GET request: %w", err)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("error making GET request: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return &StatusError{Code: response.StatusCode}
+	}
+	return json.NewDecoder(response.Body).Decode(target)
+}
+
+// MockFetcher serves fixture JSON keyed by URL instead of calling the network
+// a URL without a fixture fails like a 404 from the API would
+type MockFetcher struct {
+	Fixtures map[string][]byte
+}
+
+// Fetch decodes the fixture of url into target
+func (f MockFetcher) Fetch(ctx context.Context, url string, target interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	fixture, found := f.Fixtures[url]
+	if !found {
+		return fmt.Errorf("no fixture for %s: %w", url, &StatusError{Code: http.StatusNotFound})
+	}
+	return json.Unmarshal(fixture, target)
+}
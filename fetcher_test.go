@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMockFetcher(t *testing.T) {
+	fetcher := MockFetcher{Fixtures: apiFixtures}
+
+	var relations Relations
+	if err := fetcher.Fetch(context.Background(), fixtureAPIBaseURL+"/relation/2", &relations); err != nil {
+		t.Fatalf("Fetch = %v", err)
+	}
+	if relations.ID != 2 || len(relations.DatesLocations["paris-france"]) != 2 {
+		t.Errorf("relations = %+v", relations)
+	}
+
+	if err := fetcher.Fetch(context.Background(), fixtureAPIBaseURL+"/relation/42", &relations); err == nil {
+		t.Error("Fetch of a URL without a fixture = nil, want an error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := fetcher.Fetch(ctx, fixtureAPIBaseURL+"/artists", &[]Artists{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Fetch with a cancelled context = %v, want context.Canceled", err)
+	}
+}
+
+// waitForArtists waits for app to serve n artists, failing the test after a second
+func waitForArtists(t *testing.T, app *App, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for len(app.artists()) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d artists, want %d", len(app.artists()), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestStartDataRefresh runs the data refresh on MockFetcher fixtures, without any network access
+func TestStartDataRefresh(t *testing.T) {
+	app := newFixtureApp(t, MockFetcher{Fixtures: apiFixtures})
+	if len(app.artists()) != 2 {
+		t.Fatalf("got %d artists at startup, want 2", len(app.artists()))
+	}
+
+	// the API now has a third artist
+	fixtures := make(map[string][]byte, len(apiFixtures)+1)
+	for url, fixture := range apiFixtures {
+		fixtures[url] = fixture
+	}
+	fixtures[fixtureAPIBaseURL+"/artists"] = []byte(`[
+		{"id": 1, "name": "Queen", "relations": "http://api.test/relation/1"},
+		{"id": 2, "name": "Pink Floyd", "relations": "http://api.test/relation/2"},
+		{"id": 3, "name": "Daft Punk", "relations": "http://api.test/relation/3"}
+	]`)
+	fixtures[fixtureAPIBaseURL+"/relation/3"] = []byte(`{"id": 3, "datesLocations": {"tokyo-japan": ["05-01-2020"]}}`)
+	load := newArtistLoader(app.Config, app.Metrics, nil, MockFetcher{Fixtures: fixtures}).Load
+	// the loader is only swapped before the refresh starts, down makes it fail afterwards
+	var down atomic.Bool
+	app.loadArtists = func(ctx context.Context) ([]Artists, error) {
+		if down.Load() {
+			return nil, errors.New("API down")
+		}
+		return load(ctx)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	StartDataRefresh(ctx, app, 10*time.Millisecond)
+	waitForArtists(t, app, 3)
+	artist, found := findArtist(app.artists(), 3)
+	if !found || len(artist.DatesLocations.DatesLocations["tokyo-japan"]) != 1 {
+		t.Errorf("the new artist is %+v, want Daft Punk with its concert", artist)
+	}
+
+	// a failed refresh keeps the current artists
+	down.Store(true)
+	time.Sleep(50 * time.Millisecond)
+	if len(app.artists()) != 3 {
+		t.Errorf("got %d artists after a failed refresh, want 3", len(app.artists()))
+	}
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// applyListingQuery runs the artists through the filter and sort steps requested in the query string
+// it's shared by the HTML and JSON listings so both accept the same parameters, pagination is left to the caller
+// an error is returned when one of the parameters is invalid
+func applyListingQuery(artists []Artists, query url.Values) ([]Artists, error) {
+	minMembers, err := intParam(query, "min_members", 0)
+	if err != nil {
+		return nil, err
+	}
+	maxMembers, err := intParam(query, "max_members", math.MaxInt)
+	if err != nil {
+		return nil, err
+	}
+
+	fromYear, err := intParam(query, "from_year", math.MinInt)
+	if err != nil {
+		return nil, err
+	}
+	toYear, err := intParam(query, "to_year", math.MaxInt)
+	if err != nil {
+		return nil, err
+	}
+	if fromYear > toYear {
+		return nil, fmt.Errorf("from_year must not be after to_year")
+	}
+
+	sortKeys, err := parseSortKeys(query.Get("sort"))
+	if err != nil {
+		return nil, err
+	}
+
+	decade := query.Get("decade")
+	if decade != "" {
+		if _, err := parseDecade(decade); err != nil {
+			return nil, err
+		}
+	}
+
+	artists = filterByMemberCount(artists, minMembers, maxMembers)
+	if decade != "" {
+		artists = filterByDecade(artists, decade)
+	}
+	if country := query.Get("country"); country != "" {
+		artists = filterByCountry(artists, country)
+	}
+	if genre := query.Get("genre"); genre != "" {
+		artists = filterByGenre(artists, genre)
+	}
+	if query.Get("from_year") != "" || query.Get("to_year") != "" {
+		artists = filterByConcertYearRange(artists, fromYear, toYear)
+	}
+	return MultiSort(artists, sortKeys), nil
+}
+
+// validateFilterParams checks the numeric and decade parameters BuildFilterPipeline relies on
+func validateFilterParams(params url.Values) error {
+	for _, key := range []string{"min_members", "max_members", "from_year", "to_year"} {
+		if _, err := intParam(params, key, 0); err != nil {
+			return err
+		}
+	}
+	fromYear, _ := intParam(params, "from_year", math.MinInt)
+	toYear, _ := intParam(params, "to_year", math.MaxInt)
+	if fromYear > toYear {
+		return fmt.Errorf("from_year must not be after to_year")
+	}
+	if decade := params.Get("decade"); decade != "" {
+		if _, err := parseDecade(decade); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BuildFilterPipeline returns one filter for every non-empty parameter among name, member, country,
+// from_year, to_year, min_members, max_members, decade and genre, to be applied one after the other
+// genres maps the artist ids to their genre, the parameters are expected to be checked by validateFilterParams first
+func BuildFilterPipeline(params url.Values, genres map[int]string) []func([]Artists) []Artists {
+	var pipeline []func([]Artists) []Artists
+
+	if name := strings.TrimSpace(params.Get("name")); name != "" {
+		pipeline = append(pipeline, func(artists []Artists) []Artists {
+			return filterArtists(artists, func(artist Artists) bool {
+				return strings.Contains(strings.ToLower(artist.Name), strings.ToLower(name))
+			})
+		})
+	}
+	if member := strings.TrimSpace(params.Get("member")); member != "" {
+		pipeline = append(pipeline, func(artists []Artists) []Artists {
+			return filterArtists(artists, func(artist Artists) bool {
+				for _, m := range artist.Members {
+					if strings.Contains(strings.ToLower(m), strings.ToLower(member)) {
+						return true
+					}
+				}
+				return false
+			})
+		})
+	}
+	if country := params.Get("country"); country != "" {
+		pipeline = append(pipeline, func(artists []Artists) []Artists {
+			return filterByCountry(artists, country)
+		})
+	}
+	if params.Get("from_year") != "" || params.Get("to_year") != "" {
+		fromYear, _ := intParam(params, "from_year", math.MinInt)
+		toYear, _ := intParam(params, "to_year", math.MaxInt)
+		pipeline = append(pipeline, func(artists []Artists) []Artists {
+			return filterByConcertYearRange(artists, fromYear, toYear)
+		})
+	}
+	if params.Get("min_members") != "" || params.Get("max_members") != "" {
+		minMembers, _ := intParam(params, "min_members", 0)
+		maxMembers, _ := intParam(params, "max_members", math.MaxInt)
+		pipeline = append(pipeline, func(artists []Artists) []Artists {
+			return filterByMemberCount(artists, minMembers, maxMembers)
+		})
+	}
+	if decade := params.Get("decade"); decade != "" {
+		pipeline = append(pipeline, func(artists []Artists) []Artists {
+			return filterByDecade(artists, decade)
+		})
+	}
+	if genre := strings.TrimSpace(params.Get("genre")); genre != "" {
+		pipeline = append(pipeline, func(artists []Artists) []Artists {
+			return filterArtists(artists, func(artist Artists) bool {
+				// artists added at runtime aren't in the genres file and carry their own genre
+				artistGenre, found := genres[artist.ID]
+				if !found {
+					artistGenre = artist.Genre
+				}
+				return strings.EqualFold(artistGenre, genre)
+			})
+		})
+	}
+	return pipeline
+}
+
+// filterSummary describes the non-empty advanced search parameters, e.g. "name: queen, country: usa"
+func filterSummary(params url.Values) string {
+	var parts []string
+	for _, key := range []string{"name", "member", "country", "from_year", "to_year", "min_members", "max_members", "decade", "genre"} {
+		if value := strings.TrimSpace(params.Get(key)); value != "" {
+			parts = append(parts, strings.ReplaceAll(key, "_", " ")+": "+value)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// filterArtists keeps the artists for which keep returns true
+func filterArtists(artists []Artists, keep func(Artists) bool) []Artists {
+	var filtered []Artists
+	for _, artist := range artists {
+		if keep(artist) {
+			filtered = append(filtered, artist)
+		}
+	}
+	return filtered
+}
+
+// intParam reads an integer query parameter, returning fallback when it's absent
+func intParam(query url.Values, key string, fallback int) (int, error) {
+	value := query.Get(key)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer", key)
+	}
+	return n, nil
+}
+
+// filterByMemberCount keeps the artists whose number of members is between min and max inclusive
+func filterByMemberCount(artists []Artists, min, max int) []Artists {
+	var filtered []Artists
+	for _, artist := range artists {
+		if count := len(artist.Members); count >= min && count <= max {
+			filtered = append(filtered, artist)
+		}
+	}
+	return filtered
+}
+
+// maxSortKeys is the maximum number of comma-separated keys accepted by the sort parameter
+const maxSortKeys = 3
+
+// sortLess compares two artists on one field in ascending order
+var sortLess = map[string]func(a, b Artists) bool{
+	"name":         func(a, b Artists) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) },
+	"creationDate": func(a, b Artists) bool { return a.CreationDate < b.CreationDate },
+	"firstAlbum":   func(a, b Artists) bool { return a.FirstAlbum < b.FirstAlbum },
+	"members":      func(a, b Artists) bool { return len(a.Members) < len(b.Members) },
+}
+
+// parseSortKeys splits the comma-separated sort parameter, e.g. "name,creationDate_desc"
+// an error is returned for more than maxSortKeys keys or a field that can't be sorted on
+func parseSortKeys(value string) ([]string, error) {
+	keys := splitList(value)
+	if len(keys) > maxSortKeys {
+		return nil, fmt.Errorf("sort accepts at most %d keys", maxSortKeys)
+	}
+	for _, key := range keys {
+		if _, ok := sortLess[strings.TrimSuffix(key, "_desc")]; !ok {
+			return nil, fmt.Errorf("unknown sort field %q", key)
+		}
+	}
+	return keys, nil
+}
+
+// MultiSort returns a copy of artists sorted by the keys, the original slice is left untouched
+// the first key is the primary one and every following key breaks the ties of the previous ones
+// a key is one of name, creationDate, firstAlbum and members, the _desc suffix reverses it
+// unknown keys are skipped, no keys keeps the default order
+func MultiSort(artists []Artists, keys []string) []Artists {
+	sorted := make([]Artists, len(artists))
+	copy(sorted, artists)
+
+	var comparators []func(a, b Artists) bool
+	for _, key := range keys {
+		field, desc := strings.CutSuffix(key, "_desc")
+		less, ok := sortLess[field]
+		if !ok {
+			continue
+		}
+		if desc {
+			asc := less
+			less = func(a, b Artists) bool { return asc(b, a) }
+		}
+		comparators = append(comparators, less)
+	}
+	if len(comparators) == 0 {
+		return sorted
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		for _, less := range comparators {
+			switch {
+			case less(sorted[i], sorted[j]):
+				return true
+			case less(sorted[j], sorted[i]):
+				return false
+			}
+		}
+		return false
+	})
+	return sorted
+}
+
+// locationCountry returns the country part of a location key
+// the API uses keys like "los_angeles-usa" while some local entries use "new_york_usa", both forms are handled
+func locationCountry(location string) string {
+	if i := strings.LastIndex(location, "-"); i >= 0 {
+		return location[i+1:]
+	}
+	if i := strings.LastIndex(location, "_"); i >= 0 {
+		return location[i+1:]
+	}
+	return location
+}
+
+// normalizeCountry lowercases a country and replaces underscores so "new_zealand" matches "New Zealand"
+func normalizeCountry(country string) string {
+	return strings.ToLower(strings.TrimSpace(strings.ReplaceAll(country, "_", " ")))
+}
+
+// filterByCountry keeps the artists that have at least one concert in the given country, case insensitive
+func filterByCountry(artists []Artists, country string) []Artists {
+	country = normalizeCountry(country)
+	var filtered []Artists
+	for _, artist := range artists {
+		for location := range artist.DatesLocations.DatesLocations {
+			if normalizeCountry(locationCountry(location)) == country {
+				filtered = append(filtered, artist)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// filterByConcertYearRange keeps the artists with at least one concert between the from and to years inclusive
+// dates that can't be parsed are skipped
+func filterByConcertYearRange(artists []Artists, from, to int) []Artists {
+	var filtered []Artists
+	for _, artist := range artists {
+		if hasConcertInYears(artist, from, to) {
+			filtered = append(filtered, artist)
+		}
+	}
+	return filtered
+}
+
+// hasConcertInYears reports whether any of the artist's concert dates falls between the from and to years inclusive
+func hasConcertInYears(artist Artists, from, to int) bool {
+	for _, dates := range artist.DatesLocations.DatesLocations {
+		for _, date := range dates {
+			parsed, err := parseConcertDate(date)
+			if err != nil {
+				continue
+			}
+			if year := parsed.Year(); year >= from && year <= to {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filterByGenre keeps the artists of the given genre, case insensitive
+func filterByGenre(artists []Artists, genre string) []Artists {
+	var filtered []Artists
+	for _, artist := range artists {
+		if strings.EqualFold(artist.Genre, strings.TrimSpace(genre)) {
+			filtered = append(filtered, artist)
+		}
+	}
+	return filtered
+}
+
+// parseDecade parses a decade like "1970s" and returns its first year
+func parseDecade(decade string) (int, error) {
+	start, err := strconv.Atoi(strings.TrimSuffix(decade, "s"))
+	if err != nil || !strings.HasSuffix(decade, "s") || len(decade) != 5 || start%10 != 0 {
+		return 0, fmt.Errorf("decade must look like 1970s, got %q", decade)
+	}
+	return start, nil
+}
+
+// decadeOf returns the decade a year belongs to, e.g. 1974 → "1970s"
+func decadeOf(year int) string {
+	return strconv.Itoa(year-year%10) + "s"
+}
+
+// filterByDecade keeps the artists created during the given decade, e.g. "1970s" keeps 1970 to 1979
+// an invalid decade keeps nothing
+func filterByDecade(artists []Artists, decade string) []Artists {
+	start, err := parseDecade(decade)
+	if err != nil {
+		return nil
+	}
+	var filtered []Artists
+	for _, artist := range artists {
+		if artist.CreationDate >= start && artist.CreationDate < start+10 {
+			filtered = append(filtered, artist)
+		}
+	}
+	return filtered
+}
+
+// AvailableDecades returns the decades at least one of the artists was created in, oldest first
+func AvailableDecades(artists []Artists) []string {
+	seen := make(map[string]bool)
+	var decades []string
+	for _, artist := range artists {
+		decade := decadeOf(artist.CreationDate)
+		if !seen[decade] {
+			seen[decade] = true
+			decades = append(decades, decade)
+		}
+	}
+	sort.Strings(decades)
+	return decades
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fixtureAPIBaseURL is the API base URL of the MockFetcher fixtures, nothing listens on it
+const fixtureAPIBaseURL = "http://api.test"
+
+// apiFixtures are the responses of a small Groupie Trackers API, keyed by URL like MockFetcher expects
+var apiFixtures = map[string][]byte{
+	fixtureAPIBaseURL + "/artists": []byte(`[
+		{"id": 1, "name": "Queen", "image": "https://groupietrackers.herokuapp.com/api/images/queen.jpeg",
+		 "members": ["Freddie Mercury", "Brian May"], "creationDate": 1970, "firstAlbum": "14-12-1973",
+		 "relations": "http://api.test/relation/1"},
+		{"id": 2, "name": "Pink Floyd", "image": "https://groupietrackers.herokuapp.com/api/images/pinkfloyd.jpeg",
+		 "members": ["Roger Waters", "David Gilmour"], "creationDate": 1965, "firstAlbum": "05-08-1967",
+		 "relations": "http://api.test/relation/2"}
+	]`),
+	fixtureAPIBaseURL + "/relation/1": []byte(`{"id": 1, "datesLocations": {"london-uk": ["01-01-2020"], "paris-france": ["02-01-2020"]}}`),
+	fixtureAPIBaseURL + "/relation/2": []byte(`{"id": 2, "datesLocations": {"paris-france": ["03-01-2020", "04-01-2020"]}}`),
+}
+
+// newFixtureApp returns an App loaded from fetcher like main does, with the real templates and no rate limiting to speak of
+func newFixtureApp(t testing.TB, fetcher Fetcher) *App {
+	t.Helper()
+	app := newTestApp(t, nil)
+	app.Config.APIBaseURL = fixtureAPIBaseURL
+	app.Config.RateLimit, app.Config.RateBurst = 1000, 1000
+	loader := newArtistLoader(app.Config, app.Metrics, nil, fetcher)
+	app.loadArtists = loader.Load
+	app.relations = loader.relations
+	app.breaker = loader.breaker
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	artists, err := loader.Load(ctx)
+	if err != nil {
+		t.Fatalf("loading the fixtures: %v", err)
+	}
+	app.setArtists(artists)
+	return app
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Flags are the command-line flags of the server, they override the config file and the environment
+// only the flags given on the command line are applied, see apply
+type Flags struct {
+	// Config is the path of the JSON config file, CONFIG_FILE or config.json by default
+	Config              string
+	Port                string
+	Dev                 bool
+	LogLevel            string
+	DataRefreshInterval time.Duration
+
+	// set holds the names of the flags given on the command line
+	set map[string]bool
+}
+
+// parseFlags parses the command-line arguments, without the program name, writing the usage and the errors to output
+// --help prints the usage and returns flag.ErrHelp
+func parseFlags(args []string, output io.Writer) (*Flags, error) {
+	flags := &Flags{set: make(map[string]bool)}
+	fs := flag.NewFlagSet("groupie_tracker", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.Usage = func() {
+		fmt.Fprintf(output, "Usage: groupie_tracker [flags]\n\n")
+		fmt.Fprintf(output, "The flags override the config file, which overrides the APP_* environment variables.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&flags.Config, "config", getEnv("CONFIG_FILE", "config.json"), "path of the JSON config `file`")
+	fs.StringVar(&flags.Port, "port", "", "`port` to listen on (default "+defaultPort+")")
+	fs.BoolVar(&flags.Dev, "dev", false, "turn off the security headers and reload the templates for local development")
+	fs.StringVar(&flags.LogLevel, "log-level", "", "lowest `level` logged, debug, info, warn or error (default info)")
+	fs.DurationVar(&flags.DataRefreshInterval, "data-refresh-interval", 0, "how often the artist data is fetched again, e.g. 15m (default "+defaultRefresh.String()+")")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return nil, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	fs.Visit(func(f *flag.Flag) {
+		flags.set[f.Name] = true
+	})
+	if flags.set["log-level"] {
+		if _, err := parseLogLevel(flags.LogLevel); err != nil {
+			return nil, err
+		}
+	}
+	if flags.set["data-refresh-interval"] && flags.DataRefreshInterval <= 0 {
+		return nil, fmt.Errorf("invalid data refresh interval %s, must be positive", flags.DataRefreshInterval)
+	}
+	return flags, nil
+}
+
+// apply overrides the fields of cfg matching the flags given on the command line
+func (f *Flags) apply(cfg *Config) {
+	if f.set["port"] {
+		cfg.Port = f.Port
+	}
+	if f.set["dev"] {
+		cfg.Dev = f.Dev
+	}
+	if f.set["log-level"] {
+		cfg.LogLevel = f.LogLevel
+	}
+	if f.set["data-refresh-interval"] {
+		cfg.RefreshInterval = Duration(f.DataRefreshInterval)
+	}
+}
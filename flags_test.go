@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFlags(t *testing.T) {
+	// the environment is the lowest priority, then the config file, then the flags
+	t.Setenv("APP_PORT", "7000")
+	t.Setenv("APP_NAME", "From the environment")
+	t.Setenv("APP_LOG_LEVEL", "error")
+	path := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(path, []byte(`{"port": "8000", "logLevel": "warn", "refreshInterval": "30m", "appName": "From the file"}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flags, err := parseFlags([]string{"--config", path, "--port", "9000", "--dev", "--log-level", "debug", "--data-refresh-interval", "5m"}, io.Discard)
+	if err != nil {
+		t.Fatalf("parseFlags: %v", err)
+	}
+	if flags.Config != path {
+		t.Errorf("Config = %q, want %q", flags.Config, path)
+	}
+	cfg, err := LoadConfig(flags.Config, flags)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Port != "9000" || !cfg.Dev || cfg.LogLevel != "debug" || time.Duration(cfg.RefreshInterval) != 5*time.Minute {
+		t.Errorf("got port %s, dev %v, log level %s and refresh interval %s, want the flags", cfg.Port, cfg.Dev, cfg.LogLevel, time.Duration(cfg.RefreshInterval))
+	}
+	if cfg.AppName != "From the file" {
+		t.Errorf("AppName = %q, want the one of the file", cfg.AppName)
+	}
+
+	// without flags the file wins over the environment
+	flags, err = parseFlags([]string{"--config", path}, io.Discard)
+	if err != nil {
+		t.Fatalf("parseFlags: %v", err)
+	}
+	cfg, err = LoadConfig(flags.Config, flags)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Port != "8000" || cfg.LogLevel != "warn" || time.Duration(cfg.RefreshInterval) != 30*time.Minute {
+		t.Errorf("got port %s, log level %s and refresh interval %s, want the file", cfg.Port, cfg.LogLevel, time.Duration(cfg.RefreshInterval))
+	}
+
+	// and without the file the environment wins over the defaults
+	cfg, err = LoadConfig(filepath.Join(t.TempDir(), "missing.json"), nil)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Port != "7000" || cfg.AppName != "From the environment" || time.Duration(cfg.RefreshInterval) != defaultRefresh {
+		t.Errorf("got port %s, name %q and refresh interval %s, want the environment and the defaults", cfg.Port, cfg.AppName, time.Duration(cfg.RefreshInterval))
+	}
+}
+
+func TestParseFlagsErrors(t *testing.T) {
+	tests := [][]string{
+		{"--log-level", "loud"},
+		{"--data-refresh-interval", "0s"},
+		{"--data-refresh-interval", "-1m"},
+		{"--data-refresh-interval", "soon"},
+		{"--no-such-flag"},
+		{"extra"},
+	}
+	for _, args := range tests {
+		if _, err := parseFlags(args, io.Discard); err == nil {
+			t.Errorf("parseFlags(%v) = nil, want an error", args)
+		}
+	}
+}
+
+func TestParseFlagsHelp(t *testing.T) {
+	var output strings.Builder
+	_, err := parseFlags([]string{"--help"}, &output)
+	if !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("parseFlags(--help) = %v, want flag.ErrHelp", err)
+	}
+	for _, name := range []string{"-port", "-config", "-dev", "-log-level", "-data-refresh-interval"} {
+		if !strings.Contains(output.String(), name) {
+			t.Errorf("the usage doesn't mention %s", name)
+		}
+	}
+}
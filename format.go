@@ -0,0 +1,136 @@
+package main
+
+import (
+	"html/template"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// templateFuncs are the helper functions available in every template
+var templateFuncs = template.FuncMap{
+	"formatLocation": FormatLocation,
+	"sortedDates":    sortedDatesOrSkip,
+	"formatDate":     FormatDate,
+	"artistImage":    artistImage,
+	"year":           year,
+	"dateFormat":     dateFormat,
+	"truncate":       truncate,
+	"slugify":        slugify,
+	"safeURL":        safeURL,
+	"joinComma":      joinComma,
+	"proxyImage":     proxyImage,
+	"ogDescription":  ogDescription,
+	"nextPage":       nextPage,
+	"prevPage":       prevPage,
+}
+
+// FormatLocation turns a location key into a readable string, e.g. "new_york_usa" → "New York, USA"
+// the last segment is the country, the API separates it with a "-" ("los_angeles-usa") while local entries use a "_"
+func FormatLocation(key string) string {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return ""
+	}
+
+	var city, country string
+	if i := strings.LastIndex(key, "-"); i >= 0 {
+		city, country = key[:i], key[i+1:]
+	} else if i := strings.LastIndex(key, "_"); i >= 0 {
+		city, country = key[:i], key[i+1:]
+	} else {
+		return titleWords(key)
+	}
+
+	if city == "" {
+		return formatCountry(country)
+	}
+	return titleWords(city) + ", " + formatCountry(country)
+}
+
+// formatCountry title-cases a country, short ones like "usa" or "uk" are abbreviations and are upper-cased instead
+func formatCountry(country string) string {
+	if len(country) <= 3 {
+		return strings.ToUpper(country)
+	}
+	return titleWords(country)
+}
+
+// titleWords splits s on underscores and hyphens and capitalises the first letter of every word
+func titleWords(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool { return r == '_' || r == '-' })
+	for i, word := range words {
+		// the first letter is decoded as a rune so an accented one like the é of "élan" isn't split
+		first, size := utf8.DecodeRuneInString(word)
+		words[i] = string(unicode.ToUpper(first)) + strings.ToLower(word[size:])
+	}
+	return strings.Join(words, " ")
+}
+
+// artistImage returns the path of the local picture of an artist
+// the characters go:embed doesn't allow in file names, like the apostrophe of "Guns N' Roses", are left out of the file name
+func artistImage(name string) string {
+	clean := strings.Map(func(r rune) rune {
+		if strings.ContainsRune("\"*<>?`'|:\\", r) {
+			return -1
+		}
+		return r
+	}, name)
+	return "/static/artist_images/" + clean + ".png"
+}
+
+// dateFormat formats t with layout, the layout comes first so it reads {{.FirstAlbumDate | dateFormat "2006"}} in a template
+func dateFormat(layout string, t time.Time) string {
+	return t.Format(layout)
+}
+
+// truncate shortens s to n runes, ending it with "…" when something was cut
+func truncate(n int, s string) string {
+	runes := []rune(s)
+	if n < 0 || len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
+
+// slugify lowercases s and replaces every run of spaces and punctuation with a single hyphen, e.g. "Guns N' Roses" → "guns-n-roses"
+func slugify(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		if !hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+			hyphen = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// unsafeURL is what safeURL returns for a URL it doesn't trust, the same value html/template uses
+const unsafeURL = "#ZgotmplZ"
+
+// safeURL marks s as a trusted URL so html/template doesn't escape it
+// only relative URLs and http, https and mailto ones are trusted, anything else becomes "#ZgotmplZ"
+func safeURL(s string) template.URL {
+	parsed, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return unsafeURL
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "", "http", "https", "mailto":
+		return template.URL(parsed.String())
+	}
+	return unsafeURL
+}
+
+// joinComma joins a list with ", ", e.g. the members of an artist
+func joinComma(items []string) string {
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatLocation(t *testing.T) {
+	tests := []struct {
+		key, want string
+	}{
+		{"new_york_usa", "New York, USA"},
+		{"los_angeles-usa", "Los Angeles, USA"},
+		{"dunedin-new_zealand", "Dunedin, New Zealand"},
+		{"saint_gallen-switzerland", "Saint Gallen, Switzerland"},
+		{"paris-france", "Paris, France"},
+		{"london_uk", "London, UK"},
+		{"-germany", "Germany"},
+		{"tokyo", "Tokyo"},
+		{"élan-france", "Élan, France"},
+		{"  berlin-germany  ", "Berlin, Germany"},
+		{"", ""},
+		{"   ", ""},
+	}
+	for _, tt := range tests {
+		if got := FormatLocation(tt.key); got != tt.want {
+			t.Errorf("FormatLocation(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestTitleWords(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"new_york", "New York"},
+		{"SAO-PAULO", "Sao Paulo"},
+		{"élan", "Élan"},
+		{"örebro_östra", "Örebro Östra"},
+		{"a__b", "A B"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := titleWords(tt.s); got != tt.want {
+			t.Errorf("titleWords(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestDateFormat(t *testing.T) {
+	date := time.Date(1973, time.December, 14, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		layout, want string
+	}{
+		{"2006", "1973"},
+		{"02-01-2006", "14-12-1973"},
+		{displayDateLayout, "December 14, 1973"},
+	}
+	for _, tt := range tests {
+		if got := dateFormat(tt.layout, date); got != tt.want {
+			t.Errorf("dateFormat(%q) = %q, want %q", tt.layout, got, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		n       int
+		s, want string
+	}{
+		{5, "Queen", "Queen"},
+		{3, "Queen", "Que…"},
+		{2, "Mötley Crüe", "Mö…"},
+		{0, "Queen", "…"},
+		{-1, "Queen", "Queen"},
+		{3, "", ""},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.n, tt.s); got != tt.want {
+			t.Errorf("truncate(%d, %q) = %q, want %q", tt.n, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"Guns N' Roses", "guns-n-roses"},
+		{"  AC/DC  ", "ac-dc"},
+		{"Mötley Crüe", "mötley-crüe"},
+		{"Blink-182!", "blink-182"},
+		{"!!!", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := slugify(tt.s); got != tt.want {
+			t.Errorf("slugify(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	tests := []struct {
+		s    string
+		want template.URL
+	}{
+		{"https://example.com/a?b=c", "https://example.com/a?b=c"},
+		{"http://example.com", "http://example.com"},
+		{"mailto:band@example.com", "mailto:band@example.com"},
+		{"/artist/1", "/artist/1"},
+		{"javascript:alert(1)", unsafeURL},
+		{"JavaScript:alert(1)", unsafeURL},
+		{"data:text/html,hi", unsafeURL},
+		{"http://[::1", unsafeURL},
+	}
+	for _, tt := range tests {
+		if got := safeURL(tt.s); got != tt.want {
+			t.Errorf("safeURL(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestJoinComma(t *testing.T) {
+	tests := []struct {
+		items []string
+		want  string
+	}{
+		{[]string{"Freddie Mercury", "Brian May"}, "Freddie Mercury, Brian May"},
+		{[]string{"Prince"}, "Prince"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := joinComma(tt.items); got != tt.want {
+			t.Errorf("joinComma(%q) = %q, want %q", tt.items, got, tt.want)
+		}
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	tmpl := template.Must(template.New("").Funcs(templateFuncs).Parse(
+		`{{.Name | truncate 4}} {{.Name | slugify}} {{.Date | dateFormat "2006"}} {{joinComma .Members}} <a href="{{safeURL .Link}}">`))
+	var out strings.Builder
+	err := tmpl.Execute(&out, map[string]interface{}{
+		"Name":    "Pink Floyd",
+		"Date":    time.Date(1967, time.August, 5, 0, 0, 0, 0, time.UTC),
+		"Members": []string{"Roger Waters", "David Gilmour"},
+		"Link":    "javascript:alert(1)",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `Pink… pink-floyd 1967 Roger Waters, David Gilmour <a href="#ZgotmplZ">`
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+)
+
+// fragmentTemplate is the block of a page template holding its inner content, the part HTMX swaps in
+const fragmentTemplate = "fragment"
+
+// isHTMXRequest reports whether the request was sent by HTMX, which marks its requests with HX-Request: true
+func isHTMXRequest(r *http.Request) bool {
+	return r.Header.Get("HX-Request") == "true"
+}
+
+// renderFragment renders only the fragment block of tmpl for the HTMX requests and the whole page otherwise
+// templates without a fragment block are always rendered whole, checkNotModified marks the responses as varying on HX-Request
+func (a *App) renderFragment(w http.ResponseWriter, r *http.Request, tmpl *template.Template, data interface{}) {
+	if !isHTMXRequest(r) || tmpl.Lookup(fragmentTemplate) == nil {
+		a.renderTemplate(r.Context(), w, tmpl, data)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, fragmentTemplate, data); err != nil {
+		WriteError(r.Context(), w, InternalError(fmt.Errorf("error executing the fragment of template %s: %w", tmpl.Name(), err)), a.template("error"))
+		return
+	}
+	w.Write(buf.Bytes())
+}
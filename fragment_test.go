@@ -0,0 +1,72 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// get sends a GET request for path to server, as HTMX when htmx is set, and returns the response and its body
+func get(t *testing.T, server *httptest.Server, path string, htmx bool) (*http.Response, string) {
+	t.Helper()
+	request, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if htmx {
+		request.Header.Set("HX-Request", "true")
+	}
+	response, err := server.Client().Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response, string(body)
+}
+
+func TestHTMXFragments(t *testing.T) {
+	app := newTestApp(t, testArtists())
+	// the index goes through the page cache in production, an HTMX request must never get the cached full page
+	app.pages = NewFileCache(defaultPageCacheSize)
+	server := httptest.NewServer(newTestServer(t, app))
+	defer server.Close()
+
+	for _, path := range []string{"/", "/search?q=queen"} {
+		t.Run(path, func(t *testing.T) {
+			response, full := get(t, server, path, false)
+			if response.StatusCode != http.StatusOK || !strings.Contains(full, "<html") {
+				t.Fatalf("the page is a %d without <html>", response.StatusCode)
+			}
+
+			response, fragment := get(t, server, path, true)
+			if response.StatusCode != http.StatusOK {
+				t.Fatalf("the fragment is a %d, want %d", response.StatusCode, http.StatusOK)
+			}
+			if strings.Contains(fragment, "<html") || strings.Contains(fragment, "<head") {
+				t.Error("the fragment is a whole page")
+			}
+			if !strings.Contains(fragment, "Queen") {
+				t.Error("the fragment doesn't list Queen")
+			}
+			if !strings.Contains(full, strings.TrimSpace(fragment)) {
+				t.Error("the fragment isn't the inner content of the page")
+			}
+			if vary := response.Header.Values("Vary"); !strings.Contains(strings.Join(vary, ","), "HX-Request") {
+				t.Errorf("Vary = %v, want HX-Request in it", vary)
+			}
+		})
+	}
+
+	// any other value of the header is a regular request
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("HX-Request", "false")
+	if isHTMXRequest(request) {
+		t.Error("HX-Request: false is an HTMX request")
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// nominatimURL is the search endpoint of the OpenStreetMap geocoder
+const nominatimURL = "https://nominatim.openstreetmap.org/search"
+
+// geoLookupTimeout bounds a single geocoder request, once the rate limiter let it through
+const geoLookupTimeout = 5 * time.Second
+
+// maxGeoLookups is the number of uncached locations a single /api/artists/{id}/concerts/geo request geocodes
+// at one lookup per second a whole concert list would take too long, the others are geocoded by the next requests
+const maxGeoLookups = 3
+
+// GeoClient turns a readable location like "Los Angeles, USA" into coordinates
+// the lookup stops when ctx is done, e.g. when the client of the request needing it goes away
+type GeoClient interface {
+	Geocode(ctx context.Context, location string) (lat, lon float64, err error)
+}
+
+// geoPoint is a cached result of GeolocateLocation
+type geoPoint struct {
+	lat, lon float64
+}
+
+// geoCache maps a location key to its geoPoint, failed lookups aren't cached so they're tried again
+var geoCache sync.Map
+
+// cachedLocation returns the coordinates of a location key geocoded before
+func cachedLocation(key string) (geoPoint, bool) {
+	cached, ok := geoCache.Load(key)
+	if !ok {
+		return geoPoint{}, false
+	}
+	return cached.(geoPoint), true
+}
+
+// GeolocateLocation returns the coordinates of a concert location key like "los_angeles-usa"
+// the results are cached for the lifetime of the process since locations don't move
+func GeolocateLocation(ctx context.Context, key string, client GeoClient) (lat, lon float64, err error) {
+	if point, ok := cachedLocation(key); ok {
+		return point.lat, point.lon, nil
+	}
+	lat, lon, err = client.Geocode(ctx, FormatLocation(key))
+	if err != nil {
+		return 0, 0, fmt.Errorf("error geolocating %s: %w", key, err)
+	}
+	geoCache.Store(key, geoPoint{lat: lat, lon: lon})
+	return lat, lon, nil
+}
+
+// NominatimClient geocodes with the OpenStreetMap Nominatim API
+// its usage policy asks for at most one request per second and an identifying User-Agent
+type NominatimClient struct {
+	Client    *http.Client
+	BaseURL   string
+	UserAgent string
+
+	limiter *rate.Limiter
+}
+
+// NewNominatimClient returns a NominatimClient sending its requests with client, rate limited to one per second
+func NewNominatimClient(client *http.Client, userAgent string) *NominatimClient {
+	return &NominatimClient{
+		Client:    client,
+		BaseURL:   nominatimURL,
+		UserAgent: userAgent,
+		limiter:   rate.NewLimiter(1, 1),
+	}
+}
+
+// Geocode returns the coordinates of the best match of location
+// the wait for the rate limiter only stops with ctx, geoLookupTimeout starts once it's over
+func (c *NominatimClient) Geocode(ctx context.Context, location string) (lat, lon float64, err error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return 0, 0, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, geoLookupTimeout)
+	defer cancel()
+
+	query := url.Values{"q": {location}, "format": {"json"}, "limit": {"1"}}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error creating GET request: %w", err)
+	}
+	request.Header.Set("User-Agent", c.UserAgent)
+
+	response, err := c.Client.Do(request)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error making GET request: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return 0, 0, fmt.Errorf("received non-200 response code: %d", response.StatusCode)
+	}
+
+	// nominatim returns the coordinates as strings
+	var places []struct {
+		Lat string `json:"lat"`
+		Lon string `json:"lon"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&places); err != nil {
+		return 0, 0, fmt.Errorf("error decoding response: %w", err)
+	}
+	if len(places) == 0 {
+		return 0, 0, fmt.Errorf("no match for %q", location)
+	}
+	lat, err = strconv.ParseFloat(places[0].Lat, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid latitude %q: %w", places[0].Lat, err)
+	}
+	lon, err = strconv.ParseFloat(places[0].Lon, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid longitude %q: %w", places[0].Lon, err)
+	}
+	return lat, lon, nil
+}
+
+// StubGeoClient answers from fixed coordinates keyed by the readable location, e.g. "Paris, FRANCE"
+// it never calls the network
+type StubGeoClient struct {
+	Points map[string][2]float64
+}
+
+// Geocode returns the coordinates of location, or an error if it has none
+func (c StubGeoClient) Geocode(_ context.Context, location string) (lat, lon float64, err error) {
+	point, found := c.Points[location]
+	if !found {
+		return 0, 0, fmt.Errorf("no coordinates for %q", location)
+	}
+	return point[0], point[1], nil
+}
+
+// ConcertGeo is a concert with the coordinates of its location
+// Lat and Lon are null when the location couldn't be geolocated or hasn't been yet, see maxGeoLookups
+type ConcertGeo struct {
+	ConcertEntry
+	Lat *float64 `json:"lat"`
+	Lon *float64 `json:"lon"`
+}
+
+// apiArtistConcertsGeo responds with the concerts of artist sorted by date, with their coordinates, as JSON
+// only maxGeoLookups uncached locations are geocoded, the concerts of the others have no coordinates yet
+func (a *App) apiArtistConcertsGeo(w http.ResponseWriter, r *http.Request, artist Artists) {
+	if a.geo == nil {
+		writeJSONError(w, r, http.StatusServiceUnavailable, "Geolocation is not available")
+		return
+	}
+
+	artist = a.withRelations(r.Context(), artist)
+	// every location is looked up once even when the artist played there several times
+	// the lookups go through the request context so they stop once the client is gone
+	points := make(map[string]*geoPoint)
+	concerts := []ConcertGeo{}
+	lookups := 0
+	for _, concert := range FlattenConcerts(artist.DatesLocations) {
+		point, seen := points[concert.Location]
+		if !seen {
+			if cached, ok := cachedLocation(concert.Location); ok {
+				point = &cached
+			} else if lookups < maxGeoLookups {
+				lookups++
+				if lat, lon, err := GeolocateLocation(r.Context(), concert.Location, a.geo); err == nil {
+					point = &geoPoint{lat: lat, lon: lon}
+				}
+			}
+			points[concert.Location] = point
+		}
+		entry := ConcertGeo{ConcertEntry: concert}
+		if point != nil {
+			entry.Lat, entry.Lon = &point.lat, &point.lon
+		}
+		concerts = append(concerts, entry)
+	}
+	writeEnvelope(w, r, http.StatusOK, concerts, APIMeta{Total: len(concerts)})
+}
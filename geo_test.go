@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+// countingGeoClient is a StubGeoClient counting its lookups
+type countingGeoClient struct {
+	StubGeoClient
+	lookups atomic.Int64
+}
+
+func (c *countingGeoClient) Geocode(ctx context.Context, location string) (lat, lon float64, err error) {
+	c.lookups.Add(1)
+	return c.StubGeoClient.Geocode(ctx, location)
+}
+
+func TestAPIArtistConcertsGeoLookupLimit(t *testing.T) {
+	locations := map[string][]string{
+		"geo_one-testland":   {"01-01-2020"},
+		"geo_two-testland":   {"02-01-2020", "03-01-2020"},
+		"geo_three-testland": {"04-01-2020"},
+		"geo_four-testland":  {"05-01-2020"},
+		"geo_five-testland":  {"06-01-2020"},
+	}
+	// geoCache lives as long as the process so the locations are removed from it in case the test runs again
+	for key := range locations {
+		geoCache.Delete(key)
+	}
+	client := &countingGeoClient{StubGeoClient: StubGeoClient{Points: map[string][2]float64{}}}
+	for key := range locations {
+		client.Points[FormatLocation(key)] = [2]float64{1, 2}
+	}
+	app := newTestApp(t, []Artists{{ID: 1, Name: "Queen", DatesLocations: Relations{ID: 1, DatesLocations: locations}}})
+	app.geo = client
+	handler := newTestServer(t, app)
+
+	// located returns the number of concerts with coordinates in the response
+	located := func() int {
+		w := serve(handler, http.MethodGet, "/api/artists/1/concerts/geo", jsonHeader)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+		concerts := decodeEnvelope[[]ConcertGeo](t, w).Data
+		if len(concerts) != 6 {
+			t.Fatalf("got %d concerts, want 6", len(concerts))
+		}
+		count := 0
+		for _, concert := range concerts {
+			if concert.Lat != nil {
+				count++
+			}
+		}
+		return count
+	}
+
+	// the first request geocodes maxGeoLookups locations, the next one the rest
+	if got := located(); got < maxGeoLookups || got > maxGeoLookups+1 || client.lookups.Load() != maxGeoLookups {
+		t.Errorf("first request: %d concerts located after %d lookups, want %d lookups", got, client.lookups.Load(), maxGeoLookups)
+	}
+	if got := located(); got != 6 || client.lookups.Load() != 5 {
+		t.Errorf("second request: %d concerts located after %d lookups, want 6 after 5", got, client.lookups.Load())
+	}
+	if located(); client.lookups.Load() != 5 {
+		t.Errorf("the cached locations were geocoded again, %d lookups", client.lookups.Load())
+	}
+}
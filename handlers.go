@@ -0,0 +1,642 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// App holds everything the route handlers need, so they can be tested without starting a real server
+type App struct {
+	// Templates is read through template so it can be swapped by WatchTemplates in dev mode
+	Templates   map[string]*template.Template
+	templatesMu sync.RWMutex
+
+	// Artists is read through artists and replaced through setArtists since StartDataRefresh swaps it at runtime
+	// it's the artists loaded from the API, minus the ones deleted through the admin endpoints which stay deleted across refreshes,
+	// merged with the custom artists of the local artists file and of the admin endpoints, see mergeArtists
+	Artists        []Artists
+	artistsMu      sync.RWMutex
+	customArtists  []Artists
+	apiArtists     []Artists
+	deletedArtists map[int]bool
+
+	Config  Config
+	Metrics *Metrics
+
+	// Genres maps the artist ids to the genres of the local genres file
+	Genres map[int]string
+	// SLOs are the latency SLOs of the routes, checked by the request logger
+	SLOs []SLOConfig
+
+	// StartTime is when the server started
+	StartTime time.Time
+
+	// apiStatus is the result of the startup reachability check of the API, see checkAPIStatus
+	apiStatus atomic.Value
+
+	// loadArtists fetches a fresh copy of the artist data, used by StartDataRefresh
+	loadArtists func(ctx context.Context) ([]Artists, error)
+
+	// relations lazily fetches the concerts of the artist shown by ArtistHandler
+	relations *relationCache
+
+	// breaker is the circuit breaker of the API calls reported by /api/circuit-status
+	breaker *CircuitBreaker
+
+	// pages caches the rendered index and about pages, nil turns the cache off
+	pages *FileCache
+	// hub pushes the data updates to the /ws/refresh clients, nil turns that route off
+	hub *Hub
+	// eventClients holds the chan []byte of events of every /events/artists client as its keys
+	// eventClientCount counts them so there are never more than maxEventClients
+	eventClients     sync.Map
+	eventClientCount atomic.Int64
+
+	// client is used by checkAPIStatus, nil means defaultHTTPClient
+	client *http.Client
+	// geo geolocates the concert locations of /api/artists/{id}/concerts/geo, nil turns that route off
+	geo GeoClient
+
+	// dataETag and dataLastModified are the ETag and Last-Modified of the HTML pages, set with the artists by setArtists
+	dataETag         string
+	dataLastModified time.Time
+
+	// sitemap caches the rendered sitemap.xml, built by SitemapHandler and cleared whenever the artists change
+	sitemapMu sync.Mutex
+	sitemap   []byte
+
+	// feed caches the rendered feed.rss, built by FeedHandler and cleared whenever the artists change
+	feedMu sync.Mutex
+	feed   []byte
+
+	// locationNames caches the concert locations suggested by /api/autocomplete/locations, rebuilt whenever the artists change
+	locationNamesMu sync.RWMutex
+	locationNames   []string
+}
+
+// artists returns the current artists, the slice is never modified once set so it can be used without the lock
+func (a *App) artists() []Artists {
+	a.artistsMu.RLock()
+	defer a.artistsMu.RUnlock()
+	return a.Artists
+}
+
+// setArtists replaces the artists loaded from the API, the custom artists then replace the API ones with the same id in place
+// and the others are appended after them, see mergeArtists
+func (a *App) setArtists(artists []Artists) {
+	a.updateArtists(func() bool {
+		a.apiArtists = artists
+		return true
+	})
+}
+
+// updateArtists runs update under the write lock then, if it reports a change, rebuilds Artists
+// along with the ETag, Last-Modified, sitemap, feed and metric derived from it
+func (a *App) updateArtists(update func() bool) {
+	a.artistsMu.Lock()
+	if !update() {
+		a.artistsMu.Unlock()
+		return
+	}
+	api := make([]Artists, 0, len(a.apiArtists))
+	for _, artist := range a.apiArtists {
+		if !a.deletedArtists[artist.ID] {
+			api = append(api, artist)
+		}
+	}
+	artists := mergeArtists(api, a.customArtists)
+	etag, err := computeDataETag(artists)
+	if err != nil {
+		slog.Error("error computing data ETag", slog.Any("error", err))
+	}
+	a.Artists = artists
+	a.dataETag = etag
+	a.dataLastModified = time.Now()
+	a.artistsMu.Unlock()
+
+	a.sitemapMu.Lock()
+	a.sitemap = nil
+	a.sitemapMu.Unlock()
+	a.feedMu.Lock()
+	a.feed = nil
+	a.feedMu.Unlock()
+	names := locationNames(artists)
+	a.locationNamesMu.Lock()
+	a.locationNames = names
+	a.locationNamesMu.Unlock()
+	a.pages.SetVersion(etag)
+	a.hub.notifyRefresh(etag)
+	a.notifyEventClients(len(artists), etag)
+
+	if a.Metrics != nil {
+		a.Metrics.artistsLoaded.Set(float64(len(artists)))
+	}
+}
+
+// template returns the named template, read-locked since WatchTemplates may be replacing the map
+func (a *App) template(name string) *template.Template {
+	a.templatesMu.RLock()
+	defer a.templatesMu.RUnlock()
+	return a.Templates[name]
+}
+
+// renderTemplate renders tmpl into a buffer first and only writes it once rendering succeeded
+// so a template failing half way never sends a partial page, the error page is rendered with a 500 instead
+func (a *App) renderTemplate(ctx context.Context, w http.ResponseWriter, tmpl *template.Template, data interface{}) error {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		WriteError(ctx, w, InternalError(fmt.Errorf("error executing template %s: %w", tmpl.Name(), err)), a.template("error"))
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// IndexHandler renders the filtered, sorted and paginated artist listing
+func (a *App) IndexHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		WriteError(r.Context(), w, NotFoundError("Page not found"), a.template("error"))
+		return
+	}
+
+	// the index is registered for every method so the router doesn't turn its HEAD requests into GET ones
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		WriteError(r.Context(), w, MethodNotAllowedError(), a.template("error"))
+		return
+	}
+
+	artists := a.artists()
+	listed, err := applyListingQuery(artists, r.URL.Query())
+	if err != nil {
+		WriteError(r.Context(), w, BadRequestError(err.Error()), a.template("error"))
+		return
+	}
+
+	page, limit := paginationParams(r)
+	data := IndexPageData{
+		BasePage:     a.basePage(r, "", ""),
+		PagedArtists: paginate(listed, page, limit).withQuery(r.URL.Query()),
+		Country:      r.URL.Query().Get("country"),
+		Decade:       r.URL.Query().Get("decade"),
+		Decades:      AvailableDecades(artists),
+	}
+	if a.checkNotModified(w, r) {
+		return
+	}
+	a.renderPage(w, r, "index", data, true)
+}
+
+// AboutHandler renders the about page
+func (a *App) AboutHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/about" {
+		WriteError(r.Context(), w, NotFoundError("Page not found"), a.template("error"))
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		WriteError(r.Context(), w, MethodNotAllowedError(), a.template("error"))
+		return
+	}
+
+	if a.checkNotModified(w, r) {
+		return
+	}
+	data := AboutData{
+		BasePage:        a.basePage(r, "About", ""),
+		APIStatus:       a.APIStatus(),
+		ArtistCount:     len(a.artists()),
+		ServerStartTime: a.StartTime,
+		GoVersion:       runtime.Version(),
+	}
+	a.renderCachedPage(w, r, "about", data)
+}
+
+// AboutData represents the data passed to the about template
+type AboutData struct {
+	BasePage
+	APIStatus       string
+	ArtistCount     int
+	ServerStartTime time.Time
+	GoVersion       string
+}
+
+// API status values shown on the about page
+const (
+	apiStatusUnknown  = "unknown"
+	apiStatusOK       = "ok"
+	apiStatusDegraded = "degraded"
+)
+
+// checkAPIStatus sends a HEAD request to the API base URL and stores whether it's reachable
+// any failure or 5xx answer marks the API as degraded
+func (a *App) checkAPIStatus(ctx context.Context) {
+	status := apiStatusDegraded
+	request, err := http.NewRequestWithContext(ctx, http.MethodHead, a.Config.withDefaults().APIBaseURL, nil)
+	if err == nil {
+		client := a.client
+		if client == nil {
+			client = defaultHTTPClient
+		}
+		response, err := client.Do(request)
+		if err == nil {
+			response.Body.Close()
+			if response.StatusCode < http.StatusInternalServerError {
+				status = apiStatusOK
+			}
+		}
+	}
+	a.apiStatus.Store(status)
+	// the about page shows the status
+	a.pages.Clear()
+}
+
+// APIStatus returns the result of the last checkAPIStatus, or "unknown" if it hasn't finished yet
+func (a *App) APIStatus() string {
+	if status, ok := a.apiStatus.Load().(string); ok {
+		return status
+	}
+	return apiStatusUnknown
+}
+
+// ReadmeHandler renders the readme page
+func (a *App) ReadmeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/readme" {
+		WriteError(r.Context(), w, NotFoundError("Page not found"), a.template("error"))
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		WriteError(r.Context(), w, MethodNotAllowedError(), a.template("error"))
+		return
+	}
+
+	if a.checkNotModified(w, r) {
+		return
+	}
+	a.renderTemplate(r.Context(), w, a.template("readme"), a.basePage(r, "Readme", ""))
+}
+
+// SearchHandler renders the index template with the artists matching the q query parameter
+// search_mode=fuzzy tolerates typos using FuzzySearch, the default exact mode is a substring match
+func (a *App) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/search" {
+		WriteError(r.Context(), w, NotFoundError("Page not found"), a.template("error"))
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		WriteError(r.Context(), w, MethodNotAllowedError(), a.template("error"))
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	var results []Artists
+	switch r.URL.Query().Get("search_mode") {
+	case "", "exact":
+		results = searchArtists(a.artists(), query)
+	case "fuzzy":
+		results = FuzzySearch(query, a.artists(), defaultFuzzyThreshold)
+	default:
+		WriteError(r.Context(), w, BadRequestError("search_mode must be exact or fuzzy"), a.template("error"))
+		return
+	}
+
+	page, limit := paginationParams(r)
+	data := IndexPageData{
+		BasePage:     a.basePage(r, "Search Results", ""),
+		PagedArtists: paginate(results, page, limit).withQuery(r.URL.Query()),
+	}
+	if a.checkNotModified(w, r) {
+		return
+	}
+	a.renderPage(w, r, "index", data, false)
+}
+
+// AdvancedSearchHandler renders the index template with the artists matching every non-empty filter parameter
+// see BuildFilterPipeline for the supported parameters
+func (a *App) AdvancedSearchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/advanced-search" {
+		WriteError(r.Context(), w, NotFoundError("Page not found"), a.template("error"))
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		WriteError(r.Context(), w, MethodNotAllowedError(), a.template("error"))
+		return
+	}
+
+	params := r.URL.Query()
+	if err := validateFilterParams(params); err != nil {
+		WriteError(r.Context(), w, BadRequestError(err.Error()), a.template("error"))
+		return
+	}
+
+	results := a.artists()
+	for _, filter := range BuildFilterPipeline(params, a.Genres) {
+		results = filter(results)
+	}
+
+	page, limit := paginationParams(r)
+	data := IndexPageData{
+		BasePage:      a.basePage(r, "Advanced Search", ""),
+		PagedArtists:  paginate(results, page, limit).withQuery(r.URL.Query()),
+		FilterSummary: filterSummary(params),
+	}
+	if a.checkNotModified(w, r) {
+		return
+	}
+	a.renderTemplate(r.Context(), w, a.template("index"), data)
+}
+
+// ArtistHandler renders the detail page of the artist whose id is in the /artist/{id} path
+func (a *App) ArtistHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(r.Context(), w, MethodNotAllowedError(), a.template("error"))
+		return
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/artist/"))
+	if err != nil {
+		WriteError(r.Context(), w, NotFoundError("Page not found"), a.template("error"))
+		return
+	}
+
+	artist, found := findArtist(a.artists(), id)
+	if !found {
+		WriteError(r.Context(), w, NotFoundError("Page not found"), a.template("error"))
+		return
+	}
+
+	artist = a.withRelations(r.Context(), artist)
+	data := ArtistPageData{
+		BasePage: a.basePage(r, artist.Name, artist.Name),
+		OG:       a.artistOGMeta(artist),
+		Artist:   artist,
+		Concerts: concertRows(artist.DatesLocations),
+	}
+	data.JSONLD, err = musicGroupJSONLD(artist)
+	if err != nil {
+		slog.WarnContext(r.Context(), "error building the JSON-LD of an artist", slog.Int("artist", artist.ID), slog.Any("error", err))
+	}
+	if a.checkNotModified(w, r) {
+		return
+	}
+	a.renderPage(w, r, "artist", data, false)
+}
+
+// withRelations returns artist with its concerts fetched on demand, the ones already loaded are kept if the API fails
+func (a *App) withRelations(ctx context.Context, artist Artists) Artists {
+	if a.relations == nil {
+		return artist
+	}
+	relations, err := a.relations.Get(ctx, artist)
+	if err != nil {
+		slog.WarnContext(ctx, "error fetching relations", slog.Int("artist", artist.ID), slog.Any("error", err))
+		return artist
+	}
+	artist.DatesLocations = relations
+	return artist
+}
+
+// RandomHandler redirects to the detail page of a random artist
+func (a *App) RandomHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/random" {
+		WriteError(r.Context(), w, NotFoundError("Page not found"), a.template("error"))
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		WriteError(r.Context(), w, MethodNotAllowedError(), a.template("error"))
+		return
+	}
+
+	artists := a.artists()
+	if len(artists) == 0 {
+		WriteError(r.Context(), w, NewAppError(http.StatusServiceUnavailable, "No artists available right now"), a.template("error"))
+		return
+	}
+
+	random := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	artist := artists[random.IntN(len(artists))]
+	http.Redirect(w, r, "/artist/"+strconv.Itoa(artist.ID), http.StatusSeeOther)
+}
+
+// CompareData represents the data passed to the compare template, the two artists shown side by side
+type CompareData struct {
+	BasePage
+	Left  Artists
+	Right Artists
+}
+
+// parseCompareIDs parses the comma-separated ids query parameter, exactly two integer ids are expected
+func parseCompareIDs(value string) ([2]int, error) {
+	var ids [2]int
+	parts := strings.Split(value, ",")
+	if value == "" || len(parts) != 2 {
+		return ids, errors.New("exactly two artist ids are expected, e.g. ?ids=1,54")
+	}
+	for i, part := range parts {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return ids, errors.New("artist ids must be integers")
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// CompareHandler renders two artists side by side, their ids come from the ids query parameter
+func (a *App) CompareHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/compare" {
+		WriteError(r.Context(), w, NotFoundError("Page not found"), a.template("error"))
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		WriteError(r.Context(), w, MethodNotAllowedError(), a.template("error"))
+		return
+	}
+
+	ids, err := parseCompareIDs(r.URL.Query().Get("ids"))
+	if err != nil {
+		WriteError(r.Context(), w, BadRequestError(err.Error()), a.template("error"))
+		return
+	}
+
+	artists := a.artists()
+	left, leftFound := findArtist(artists, ids[0])
+	right, rightFound := findArtist(artists, ids[1])
+	if !leftFound || !rightFound {
+		WriteError(r.Context(), w, BadRequestError("Both artists must exist"), a.template("error"))
+		return
+	}
+
+	if a.checkNotModified(w, r) {
+		return
+	}
+	data := CompareData{
+		BasePage: a.basePage(r, left.Name+" vs "+right.Name, ""),
+		Left:     left,
+		Right:    right,
+	}
+	a.renderTemplate(r.Context(), w, a.template("compare"), data)
+}
+
+// APIArtistsHandler responds with the filtered, sorted and paginated artists as JSON
+// the fields query parameter limits every artist to the listed JSON fields, see projectArtist
+func (a *App) APIArtistsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	listed, err := applyListingQuery(a.artists(), r.URL.Query())
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	fields, err := parseFields(r.URL.Query().Get("fields"))
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, limit := paginationParams(r)
+	paged := paginate(listed, page, limit)
+	meta := APIMeta{
+		Total:   len(listed),
+		Page:    paged.Page,
+		PerPage: paged.Limit,
+	}
+	// ?fields=id,name only sends those fields of every artist
+	if len(fields) > 0 {
+		projected := make([]map[string]interface{}, 0, len(paged.Artists))
+		for _, artist := range paged.Artists {
+			projected = append(projected, projectArtist(artist, fields))
+		}
+		writeEnvelope(w, r, http.StatusOK, projected, meta)
+		return
+	}
+	writeEnvelope(w, r, http.StatusOK, paged.Artists, meta)
+}
+
+// APIArtistHandler responds with the artist whose id is in the /api/artists/{id} path as JSON
+// /api/artists/{id}/concerts responds with its concerts instead, see apiArtistConcerts
+// /api/artists/{id}/concerts/geo with its concerts and their coordinates, see apiArtistConcertsGeo
+// /api/artists/{id}/related with the artists related to it, see apiArtistRelated
+// and /api/artists/{id}/dates with its concerts as an iCalendar file, see apiArtistDates
+func (a *App) APIArtistHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// the path is /api/artists/{id} or /api/artists/{id}/ followed by concerts, concerts/geo, related or dates
+	idPart, sub, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/artists/"), "/")
+	id, err := strconv.Atoi(idPart)
+	if err != nil || (sub != "" && sub != "concerts" && sub != "concerts/geo" && sub != "related" && sub != "dates") {
+		writeJSONError(w, r, http.StatusNotFound, "Artist not found")
+		return
+	}
+
+	artist, found := findArtist(a.artists(), id)
+	if !found {
+		writeJSONError(w, r, http.StatusNotFound, "Artist not found")
+		return
+	}
+	switch sub {
+	case "concerts":
+		a.apiArtistConcerts(w, r, artist)
+		return
+	case "concerts/geo":
+		a.apiArtistConcertsGeo(w, r, artist)
+		return
+	case "related":
+		a.apiArtistRelated(w, r, artist)
+		return
+	case "dates":
+		a.apiArtistDates(w, r, artist)
+		return
+	}
+
+	w.Header().Set("Location", "/artist/"+strconv.Itoa(artist.ID))
+	writeEnvelope(w, r, http.StatusOK, artist, APIMeta{Total: 1})
+}
+
+// HealthHandler reports that the server is up and listening
+func (a *App) HealthHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, HealthStatus{Status: "ok"})
+}
+
+// ReadyHandler reports whether the artist data and the templates have been loaded and the server can serve traffic
+func (a *App) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	if len(a.artists()) == 0 {
+		writeJSON(w, http.StatusServiceUnavailable, HealthStatus{Status: "not ready", Reason: "artists not loaded"})
+		return
+	}
+	a.templatesMu.RLock()
+	err := TemplateHealth(a.Templates, requiredTemplates)
+	a.templatesMu.RUnlock()
+	var missing *MissingTemplatesError
+	if errors.As(err, &missing) {
+		writeJSON(w, http.StatusServiceUnavailable, HealthStatus{Status: "not ready", Reason: "templates not loaded", MissingTemplates: missing.Missing})
+		return
+	}
+	writeJSON(w, http.StatusOK, HealthStatus{Status: "ok"})
+}
+
+// APIAutocompleteHandler responds with up to 10 search suggestions starting with the q query parameter as JSON
+// q needs at least 2 characters
+func (a *App) APIAutocompleteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if len([]rune(query)) < 2 {
+		writeJSONError(w, r, http.StatusBadRequest, "q must be at least 2 characters")
+		return
+	}
+
+	suggestions := Autocomplete(query, a.artists(), 10)
+	writeEnvelope(w, r, http.StatusOK, suggestions, APIMeta{Total: len(suggestions)})
+}
+
+// APILocationAutocompleteHandler responds with up to 10 concert locations starting with the q query parameter
+// as a flat JSON array of strings for the location filter, q needs at least 2 characters
+func (a *App) APILocationAutocompleteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if len([]rune(query)) < 2 {
+		writeJSONError(w, r, http.StatusBadRequest, "q must be at least 2 characters")
+		return
+	}
+
+	a.locationNamesMu.RLock()
+	names := a.locationNames
+	a.locationNamesMu.RUnlock()
+	writeJSON(w, http.StatusOK, completeLocation(names, query, 10))
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"html/template"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testArtists are the synthetic artists the handler tests are served
+func testArtists() []Artists {
+	return []Artists{
+		{
+			ID: 1, Name: "Queen", Members: []string{"Freddie Mercury", "Brian May"},
+			CreationDate: 1970, FirstAlbum: "14-12-1973", Genre: "Rock",
+			DatesLocations: Relations{ID: 1, DatesLocations: map[string][]string{"london-uk": {"01-01-2020"}}},
+		},
+		{
+			ID: 2, Name: "Pink Floyd", Members: []string{"Roger Waters", "David Gilmour"},
+			CreationDate: 1965, FirstAlbum: "05-08-1967", Genre: "Rock",
+			DatesLocations: Relations{ID: 2, DatesLocations: map[string][]string{"paris-france": {"02-02-2020"}}},
+		},
+		{
+			ID: 3, Name: "Daft Punk", Members: []string{"Thomas Bangalter", "Guy-Manuel de Homem-Christo"},
+			CreationDate: 1993, FirstAlbum: "20-01-1997", Genre: "Electronic",
+			DatesLocations: Relations{ID: 3, DatesLocations: map[string][]string{"paris-france": {"03-03-2020"}}},
+		},
+	}
+}
+
+// newTestApp returns an App serving artists with the real templates and the default config
+func newTestApp(t testing.TB, artists []Artists) *App {
+	t.Helper()
+	templates, err := loadTemplates("templates")
+	if err != nil {
+		t.Fatalf("loading the templates: %v", err)
+	}
+	app := &App{
+		Templates: templates,
+		Config:    Config{}.withDefaults(),
+		Metrics:   NewMetrics(),
+		StartTime: time.Now(),
+	}
+	app.setArtists(artists)
+	return app
+}
+
+// newTestServer returns the full router of app, with its middlewares, logging nowhere
+// the background work of the middlewares stops at the end of the test
+func newTestServer(t testing.TB, app *App) http.Handler {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	handler, err := buildRouter(ctx, app, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("building the router: %v", err)
+	}
+	return handler
+}
+
+// serve sends a request through handler and returns the recorded response
+func serve(handler http.Handler, method, target string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	for key, values := range header {
+		r.Header[key] = values
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandlers(t *testing.T) {
+	handler := newTestServer(t, newTestApp(t, testArtists()))
+
+	t.Run("index", func(t *testing.T) {
+		w := serve(handler, http.MethodGet, "/", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET / = %d, want %d", w.Code, http.StatusOK)
+		}
+		assertRobotsTag(t, w, "index, follow")
+		for _, name := range []string{"Queen", "Pink Floyd", "Daft Punk"} {
+			if !strings.Contains(w.Body.String(), name) {
+				t.Errorf("GET / doesn't list %s", name)
+			}
+		}
+	})
+
+	t.Run("index post", func(t *testing.T) {
+		w := serve(handler, http.MethodPost, "/", nil)
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("POST / = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+		}
+	})
+
+	t.Run("unknown path", func(t *testing.T) {
+		w := serve(handler, http.MethodGet, "/no-such-page", nil)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("GET /no-such-page = %d, want %d", w.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("restricted path", func(t *testing.T) {
+		for _, path := range []string{"/static", "/static/", "/assets"} {
+			w := serve(handler, http.MethodGet, path, nil)
+			if w.Code != http.StatusForbidden {
+				t.Errorf("GET %s = %d, want %d", path, w.Code, http.StatusForbidden)
+			}
+		}
+	})
+
+	t.Run("api artists", func(t *testing.T) {
+		w := serve(handler, http.MethodGet, "/api/artists", jsonHeader)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /api/artists = %d, want %d", w.Code, http.StatusOK)
+		}
+		assertRobotsTag(t, w, "noindex, nofollow")
+		body := decodeEnvelope[[]Artists](t, w)
+		if len(body.Data) != 3 || body.Meta.Total != 3 {
+			t.Errorf("got %d artists and a total of %d, want 3 and 3", len(body.Data), body.Meta.Total)
+		}
+		if body.Meta.RequestID == "" {
+			t.Error("meta has no requestId")
+		}
+	})
+
+	t.Run("export", func(t *testing.T) {
+		w := serve(handler, http.MethodGet, "/export/artists.csv", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /export/artists.csv = %d, want %d", w.Code, http.StatusOK)
+		}
+		assertRobotsTag(t, w, "noindex, nofollow")
+	})
+
+	t.Run("api error", func(t *testing.T) {
+		w := serve(handler, http.MethodGet, "/api/artists/42", jsonHeader)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("GET /api/artists/42 = %d, want %d", w.Code, http.StatusNotFound)
+		}
+		assertRobotsTag(t, w, "noindex, nofollow")
+		envelope := decodeEnvelope[any](t, w)
+		if envelope.Error == nil || envelope.Error.Code != http.StatusNotFound || envelope.Error.Message == "" {
+			t.Errorf("error = %+v, want a 404 with a message", envelope.Error)
+		}
+	})
+
+	t.Run("search", func(t *testing.T) {
+		w := serve(handler, http.MethodGet, "/search?q=queen", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /search?q=queen = %d, want %d", w.Code, http.StatusOK)
+		}
+		assertRobotsTag(t, w, "index, follow")
+		if !strings.Contains(w.Body.String(), "Queen") {
+			t.Error("the results don't list Queen")
+		}
+		if strings.Contains(w.Body.String(), "Daft Punk") {
+			t.Error("the results list Daft Punk")
+		}
+	})
+
+	t.Run("empty search", func(t *testing.T) {
+		w := serve(handler, http.MethodGet, "/search?q=", nil)
+		if w.Code != http.StatusFound || w.Header().Get("Location") != "/" {
+			t.Errorf("GET /search?q= = %d to %q, want %d to /", w.Code, w.Header().Get("Location"), http.StatusFound)
+		}
+	})
+
+	t.Run("artist", func(t *testing.T) {
+		w := serve(handler, http.MethodGet, "/artist/2", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /artist/2 = %d, want %d", w.Code, http.StatusOK)
+		}
+		assertRobotsTag(t, w, "index, follow")
+		if !strings.Contains(w.Body.String(), "Pink Floyd") {
+			t.Error("the page doesn't show Pink Floyd")
+		}
+	})
+
+	t.Run("missing artist", func(t *testing.T) {
+		for _, path := range []string{"/artist/42", "/artist/abc"} {
+			w := serve(handler, http.MethodGet, path, nil)
+			if w.Code != http.StatusNotFound {
+				t.Errorf("GET %s = %d, want %d", path, w.Code, http.StatusNotFound)
+			}
+		}
+	})
+}
+
+// assertRobotsTag checks the X-Robots-Tag header of the response
+func assertRobotsTag(t *testing.T, w *httptest.ResponseRecorder, want string) {
+	t.Helper()
+	if got := w.Header().Get("X-Robots-Tag"); got != want {
+		t.Errorf("X-Robots-Tag = %q, want %q", got, want)
+	}
+}
+
+func TestHandlersBrokenTemplate(t *testing.T) {
+	app := newTestApp(t, testArtists())
+	// the index data has no Missing field so executing the template fails
+	app.Templates["index"] = template.Must(template.New("index").Parse(`{{.Missing}}`))
+	handler := newTestServer(t, app)
+
+	w := serve(handler, http.MethodGet, "/", nil)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("GET / = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestParseCompareIDs(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    [2]int
+		wantErr bool
+	}{
+		{value: "1,54", want: [2]int{1, 54}},
+		{value: " 2 , 3 ", want: [2]int{2, 3}},
+		{value: "", wantErr: true},
+		{value: "1", wantErr: true},
+		{value: "1,", wantErr: true},
+		{value: "1,2,3", wantErr: true},
+		{value: "1,abc", wantErr: true},
+		{value: "1.5,2", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseCompareIDs(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCompareIDs(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseCompareIDs(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestCompareHandler(t *testing.T) {
+	handler := newTestServer(t, newTestApp(t, testArtists()))
+	tests := []struct {
+		method, target string
+		want           int
+	}{
+		{http.MethodGet, "/compare?ids=1,2", http.StatusOK},
+		{http.MethodGet, "/compare?ids=1,42", http.StatusBadRequest},
+		{http.MethodGet, "/compare?ids=1", http.StatusBadRequest},
+		{http.MethodGet, "/compare?ids=1,2,3", http.StatusBadRequest},
+		{http.MethodPost, "/compare?ids=1,2", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		w := serve(handler, tt.method, tt.target, nil)
+		if w.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.target, w.Code, tt.want)
+		}
+	}
+
+	w := serve(handler, http.MethodGet, "/compare?ids=1,2", nil)
+	for _, name := range []string{"Queen", "Pink Floyd"} {
+		if !strings.Contains(w.Body.String(), name) {
+			t.Errorf("the comparison doesn't show %s", name)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// defaultHTTPClient is used when no client was configured, e.g. by a zero-value HTTPFetcher
+var defaultHTTPClient = NewHTTPClient(Config{})
+
+// NewHTTPClient returns the client used for every upstream API call
+// its connection pool and transport timeouts come from cfg so a hung API can't hang the server
+func NewHTTPClient(cfg Config) *http.Client {
+	cfg = cfg.withDefaults()
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout: time.Duration(cfg.HTTPDialTimeout),
+			}).DialContext,
+			MaxIdleConnsPerHost:   cfg.HTTPMaxIdleConnsPerHost,
+			IdleConnTimeout:       time.Duration(cfg.HTTPIdleConnTimeout),
+			TLSHandshakeTimeout:   time.Duration(cfg.HTTPTLSHandshakeTimeout),
+			ResponseHeaderTimeout: time.Duration(cfg.HTTPResponseHeaderTimeout),
+		},
+	}
+}
+
+// roundTripperFunc lets a function be used as an http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// InstrumentClient wraps the transport of client so its in-flight requests and transport errors are reported
+func (m *Metrics) InstrumentClient(client *http.Client) {
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	counted := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		response, err := next.RoundTrip(r)
+		if err != nil {
+			m.clientErrors.Inc()
+		}
+		return response, err
+	})
+	client.Transport = promhttp.InstrumentRoundTripperInFlight(m.clientInFlight, counted)
+}
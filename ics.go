@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// icsDateLayout and icsTimestampLayout are the DATE and UTC DATE-TIME layouts of iCalendar
+const (
+	icsDateLayout      = "20060102"
+	icsTimestampLayout = "20060102T150405Z"
+)
+
+// icsMaxLineLength is the most octets an iCalendar line can have before it's folded
+const icsMaxLineLength = 75
+
+// icsEscaper escapes the characters iCalendar text values can't carry as is
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+// RenderICS returns the concerts of artist as an iCalendar file, one all-day VEVENT per concert date sorted by date
+// an invalid concert date is an error rather than a missing event
+func RenderICS(artist Artists) ([]byte, error) {
+	type event struct {
+		location string
+		date     time.Time
+	}
+	events := []event{}
+	for location, dates := range artist.DatesLocations.DatesLocations {
+		for _, date := range dates {
+			parsed, err := parseConcertDate(date)
+			if err != nil {
+				return nil, fmt.Errorf("invalid concert date %q: %w", date, err)
+			}
+			events = append(events, event{location, parsed})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].date.Equal(events[j].date) {
+			return events[i].date.Before(events[j].date)
+		}
+		return events[i].location < events[j].location
+	})
+
+	var b bytes.Buffer
+	line := func(name, value string) {
+		writeICSLine(&b, name+":"+value)
+	}
+	stamp := time.Now().UTC().Format(icsTimestampLayout)
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//groupie_tracker//concerts//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", icsEscaper.Replace(artist.Name+" concerts"))
+	for _, event := range events {
+		display := FormatLocation(event.location)
+		line("BEGIN", "VEVENT")
+		line("UID", fmt.Sprintf("%d-%s-%s@groupie-tracker", artist.ID, event.date.Format(icsDateLayout), slugify(event.location)))
+		line("DTSTAMP", stamp)
+		line("DTSTART;VALUE=DATE", event.date.Format(icsDateLayout))
+		line("SUMMARY", icsEscaper.Replace(artist.Name+" - "+display))
+		line("LOCATION", icsEscaper.Replace(display))
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return b.Bytes(), nil
+}
+
+// writeICSLine writes content as an iCalendar line ending in CRLF
+// lines longer than 75 octets are folded onto continuation lines starting with a space, without splitting a UTF-8 character
+func writeICSLine(b *bytes.Buffer, content string) {
+	limit := icsMaxLineLength
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(content[cut]) {
+			cut--
+		}
+		b.WriteString(content[:cut])
+		b.WriteString("\r\n ")
+		content = content[cut:]
+		// the leading space of a continuation line counts towards its length
+		limit = icsMaxLineLength - 1
+	}
+	b.WriteString(content)
+	b.WriteString("\r\n")
+}
+
+// isRuneStart reports whether c is the first byte of a UTF-8 character
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
+
+// apiArtistDates responds with the concerts of artist as an iCalendar attachment named after the artist
+func (a *App) apiArtistDates(w http.ResponseWriter, r *http.Request, artist Artists) {
+	artist = a.withRelations(r.Context(), artist)
+	ics, err := RenderICS(artist)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "error rendering the concerts calendar", slog.Int("artist", artist.ID), slog.Any("error", err))
+		writeJSONError(w, r, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-concerts.ics"`, slugify(artist.Name)))
+	w.Write(ics)
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"container/list"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// imageCacheTTL is how long a proxied image is kept in memory
+const imageCacheTTL = time.Hour
+
+// maxImageSize bounds the size of a proxied image
+const maxImageSize = 5 << 20
+
+// maxImageCacheEntries and maxImageCacheBytes bound the images kept by imageCache
+const (
+	maxImageCacheEntries = 200
+	maxImageCacheBytes   = 64 << 20
+)
+
+// maxImageRedirects is the number of redirects the image proxy follows, like the default of net/http
+const maxImageRedirects = 10
+
+// cachedImage is an image fetched by the image proxy
+type cachedImage struct {
+	url         string
+	contentType string
+	body        []byte
+	fetchedAt   time.Time
+}
+
+// ImageCache keeps the last proxied images in memory like FileCache keeps the pages
+// the least recently used ones are evicted once there are maxEntries of them or they take more than maxBytes,
+// and an image older than ttl is never served
+type ImageCache struct {
+	maxEntries int
+	maxBytes   int
+	ttl        time.Duration
+
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// NewImageCache returns an empty ImageCache
+func NewImageCache(maxEntries, maxBytes int, ttl time.Duration) *ImageCache {
+	return &ImageCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		ttl:        ttl,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// imageCache holds the images served by ImageProxyHandler
+var imageCache = NewImageCache(maxImageCacheEntries, maxImageCacheBytes, imageCacheTTL)
+
+// Get returns the image of url and marks it as recently used, an expired image is removed instead
+func (c *ImageCache) Get(url string) (cachedImage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, found := c.entries[url]
+	if !found {
+		return cachedImage{}, false
+	}
+	image := element.Value.(cachedImage)
+	if time.Since(image.fetchedAt) >= c.ttl {
+		c.remove(element)
+		return cachedImage{}, false
+	}
+	c.order.MoveToFront(element)
+	return image, true
+}
+
+// Put caches image, dropping the expired images then the least recently used ones until it fits
+func (c *ImageCache) Put(image cachedImage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, found := c.entries[image.url]; found {
+		c.remove(element)
+	}
+	for element := c.order.Back(); element != nil; {
+		previous := element.Prev()
+		if time.Since(element.Value.(cachedImage).fetchedAt) >= c.ttl {
+			c.remove(element)
+		}
+		element = previous
+	}
+	c.entries[image.url] = c.order.PushFront(image)
+	c.size += len(image.body)
+	for c.order.Len() > c.maxEntries || (c.size > c.maxBytes && c.order.Len() > 1) {
+		c.remove(c.order.Back())
+	}
+}
+
+// remove drops element from the cache, c.mu must be held
+func (c *ImageCache) remove(element *list.Element) {
+	image := c.order.Remove(element).(cachedImage)
+	delete(c.entries, image.url)
+	c.size -= len(image.body)
+}
+
+// proxyImage returns the path of raw through the image proxy, so images of the API are served over the site's own scheme
+// relative paths like the local pictures are returned unchanged
+func proxyImage(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return raw
+	}
+	return "/proxy/image?url=" + url.QueryEscape(raw)
+}
+
+// imageHostAllowed reports whether the host of image is one of the configured ImageProxyHosts
+func (a *App) imageHostAllowed(image *url.URL) bool {
+	if image.Scheme != "http" && image.Scheme != "https" {
+		return false
+	}
+	for _, host := range a.Config.withDefaults().ImageProxyHosts {
+		if strings.EqualFold(image.Hostname(), host) {
+			return true
+		}
+	}
+	return false
+}
+
+// imageClient returns the client of the image proxy, a copy of the App client checking every redirect against the allowed hosts
+// so an allowed host can't send the proxy anywhere else
+func (a *App) imageClient() *http.Client {
+	client := a.client
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	proxyClient := *client
+	proxyClient.CheckRedirect = func(request *http.Request, via []*http.Request) error {
+		if len(via) >= maxImageRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxImageRedirects)
+		}
+		if !a.imageHostAllowed(request.URL) {
+			return fmt.Errorf("redirect to %s is not an allowed image host", request.URL.Host)
+		}
+		return nil
+	}
+	return &proxyClient
+}
+
+// fetchImage returns the image at imageURL, from the cache while it's younger than imageCacheTTL
+func (a *App) fetchImage(r *http.Request, imageURL string) (cachedImage, error) {
+	if image, ok := imageCache.Get(imageURL); ok {
+		return image, nil
+	}
+
+	request, err := http.NewRequestWithContext(r.Context(), http.MethodGet, imageURL, nil)
+	if err != nil {
+		return cachedImage{}, fmt.Errorf("error creating GET request: %w", err)
+	}
+	response, err := a.imageClient().Do(request)
+	if err != nil {
+		return cachedImage{}, fmt.Errorf("error making GET request: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return cachedImage{}, fmt.Errorf("received non-200 response code: %d", response.StatusCode)
+	}
+	contentType := response.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		return cachedImage{}, fmt.Errorf("not an image: %q", contentType)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxImageSize+1))
+	if err != nil {
+		return cachedImage{}, fmt.Errorf("error reading image: %w", err)
+	}
+	if len(body) > maxImageSize {
+		return cachedImage{}, fmt.Errorf("image is larger than %d bytes", maxImageSize)
+	}
+
+	image := cachedImage{url: imageURL, contentType: contentType, body: body, fetchedAt: time.Now()}
+	imageCache.Put(image)
+	return image, nil
+}
+
+// ImageProxyHandler serves the image of the url query parameter, fetched from one of the allowed image hosts
+// it lets the pages show the API's http images without mixed content warnings once the site runs on HTTPS
+func (a *App) ImageProxyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(r.Context(), w, MethodNotAllowedError(), a.template("error"))
+		return
+	}
+
+	raw := r.URL.Query().Get("url")
+	image, err := url.Parse(raw)
+	if raw == "" || err != nil {
+		WriteError(r.Context(), w, BadRequestError("url must be an image URL"), a.template("error"))
+		return
+	}
+	if !a.imageHostAllowed(image) {
+		WriteError(r.Context(), w, ForbiddenError("Access Denied"), a.template("error"))
+		return
+	}
+
+	fetched, err := a.fetchImage(r, image.String())
+	if err != nil {
+		WriteError(r.Context(), w, &AppError{HTTPCode: http.StatusBadGateway, Message: "The image could not be loaded", Internal: err}, a.template("error"))
+		return
+	}
+	w.Header().Set("Content-Type", fetched.contentType)
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	// an SVG opened directly must not be able to run scripts on our origin
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	w.Write(fetched.body)
+}
@@ -0,0 +1,117 @@
+//go:build integration
+
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestIntegrationRoutes starts a real server on the full router and hits every documented route over HTTP
+// the artists come from the MockFetcher fixtures so the API is never called, run it with go test -tags integration
+func TestIntegrationRoutes(t *testing.T) {
+	app := newFixtureApp(t, MockFetcher{Fixtures: apiFixtures})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handler, err := buildRouter(ctx, app, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("building the router: %v", err)
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	client := server.Client()
+	// the redirects are checked themselves rather than followed
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	tests := []struct {
+		method, path string
+		want         int
+		contentType  string
+	}{
+		{http.MethodGet, "/", http.StatusOK, "text/html"},
+		{http.MethodHead, "/about", http.StatusOK, "text/html"},
+		{http.MethodGet, "/about", http.StatusOK, "text/html"},
+		{http.MethodGet, "/readme", http.StatusOK, "text/html"},
+		{http.MethodGet, "/search?q=queen", http.StatusOK, "text/html"},
+		{http.MethodGet, "/search?q=", http.StatusFound, ""},
+		{http.MethodGet, "/advanced-search?country=france", http.StatusOK, "text/html"},
+		{http.MethodGet, "/artist/1", http.StatusOK, "text/html"},
+		{http.MethodGet, "/artist/42", http.StatusNotFound, "text/html"},
+		{http.MethodGet, "/compare?ids=1,2", http.StatusOK, "text/html"},
+		{http.MethodGet, "/random", http.StatusSeeOther, ""},
+		{http.MethodGet, "/sitemap.xml", http.StatusOK, "application/xml"},
+		{http.MethodGet, "/robots.txt", http.StatusOK, "text/plain"},
+		{http.MethodGet, "/feed.rss", http.StatusOK, "application/rss+xml"},
+		{http.MethodGet, "/no-such-page", http.StatusNotFound, "text/html"},
+		{http.MethodPost, "/about", http.StatusMethodNotAllowed, "text/html"},
+
+		{http.MethodGet, "/static/style.css", http.StatusOK, "text/css"},
+		{http.MethodGet, "/assets/Home.svg", http.StatusOK, "image/svg+xml"},
+		{http.MethodGet, "/static/missing.css", http.StatusNotFound, "text/html"},
+		{http.MethodGet, "/static/", http.StatusForbidden, "text/html"},
+		{http.MethodGet, "/favicon.ico", http.StatusOK, ""},
+		{http.MethodGet, "/manifest.json", http.StatusOK, "application/manifest+json"},
+
+		{http.MethodGet, "/health", http.StatusOK, "application/json"},
+		{http.MethodGet, "/ready", http.StatusOK, "application/json"},
+		{http.MethodGet, "/metrics", http.StatusOK, "text/plain"},
+
+		{http.MethodGet, "/export/artists.csv", http.StatusOK, "text/csv"},
+
+		{http.MethodGet, "/api/artists", http.StatusOK, "application/json"},
+		{http.MethodGet, "/api/artists?fields=id,name", http.StatusOK, "application/json"},
+		{http.MethodGet, "/api/artists/1", http.StatusOK, "application/json"},
+		{http.MethodGet, "/api/artists/42", http.StatusNotFound, "application/json"},
+		{http.MethodGet, "/api/artists/1/concerts", http.StatusOK, "application/json"},
+		{http.MethodGet, "/api/artists/1/related", http.StatusOK, "application/json"},
+		{http.MethodGet, "/api/artists/1/dates", http.StatusOK, "text/calendar"},
+		{http.MethodGet, "/api/stats/top-locations", http.StatusOK, "application/json"},
+		{http.MethodGet, "/api/stats/bands-by-decade", http.StatusOK, "application/json"},
+		{http.MethodGet, "/api/stats/shared-members", http.StatusOK, "application/json"},
+		{http.MethodGet, "/api/stats/member-count-distribution", http.StatusOK, "application/json"},
+		{http.MethodGet, "/api/stats/longest-career", http.StatusOK, "application/json"},
+		{http.MethodGet, "/api/locations", http.StatusOK, "application/json"},
+		{http.MethodGet, "/api/version", http.StatusOK, "application/json"},
+		{http.MethodGet, "/api/autocomplete?q=qu", http.StatusOK, "application/json"},
+		{http.MethodGet, "/api/autocomplete/locations?q=pa", http.StatusOK, "application/json"},
+		{http.MethodGet, "/api/circuit-status", http.StatusUnauthorized, "application/json"},
+		{http.MethodGet, "/api/no-such-endpoint", http.StatusNotFound, "application/json"},
+		{http.MethodPost, "/admin/artists", http.StatusUnauthorized, "application/json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			request, err := http.NewRequest(tt.method, server.URL+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			request.Header.Set("Accept", "*/*")
+			response, err := client.Do(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer response.Body.Close()
+			io.Copy(io.Discard, response.Body)
+
+			if response.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", response.StatusCode, tt.want)
+			}
+			if contentType := response.Header.Get("Content-Type"); !strings.HasPrefix(contentType, tt.contentType) {
+				t.Errorf("Content-Type = %q, want %s", contentType, tt.contentType)
+			}
+			// every response goes through the outer middlewares
+			if response.Header.Get("X-Request-ID") == "" {
+				t.Error("no X-Request-ID header")
+			}
+			if response.Header.Get("X-Content-Type-Options") != "nosniff" {
+				t.Error("no X-Content-Type-Options header")
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"html/template"
+)
+
+// MusicGroupLD is the schema.org MusicGroup of an artist, embedded as JSON-LD in its detail page
+type MusicGroupLD struct {
+	Context      string     `json:"@context"`
+	Type         string     `json:"@type"`
+	Name         string     `json:"name"`
+	Image        string     `json:"image,omitempty"`
+	FoundingDate int        `json:"foundingDate,omitempty"`
+	Member       []PersonLD `json:"member,omitempty"`
+}
+
+// PersonLD is a schema.org Person, a member of a MusicGroupLD
+type PersonLD struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+// BuildMusicGroupLD returns the MusicGroup structured data of a
+func BuildMusicGroupLD(a Artists) MusicGroupLD {
+	group := MusicGroupLD{
+		Context:      "https://schema.org",
+		Type:         "MusicGroup",
+		Name:         a.Name,
+		Image:        a.Image,
+		FoundingDate: a.CreationDate,
+	}
+	for _, member := range a.Members {
+		group.Member = append(group.Member, PersonLD{Type: "Person", Name: member})
+	}
+	return group
+}
+
+// musicGroupJSONLD marshals the MusicGroup of a for a <script type="application/ld+json"> tag
+// json.Marshal escapes <, > and & so the data can't close the script tag
+func musicGroupJSONLD(a Artists) (template.JS, error) {
+	data, err := json.Marshal(BuildMusicGroupLD(a))
+	if err != nil {
+		return "", err
+	}
+	return template.JS(data), nil
+}
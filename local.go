@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// LoadLocalArtists reads the JSON file at path listing artists in the format of the API, like The Weeknd who isn't part of it
+// their concerts are read from the file too since they have no relations URL
+// a missing file isn't an error, there are simply no local artists
+func LoadLocalArtists(path string) ([]Artists, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading local artists file: %w", err)
+	}
+	var artists []Artists
+	if err := json.Unmarshal(data, &artists); err != nil {
+		return nil, fmt.Errorf("error parsing local artists file %s: %w", path, err)
+	}
+	for i := range artists {
+		// only what identifies an artist is required, the local data is trusted otherwise
+		if artists[i].ID <= 0 || artists[i].Name == "" {
+			return nil, fmt.Errorf("local artist %d of %s needs a positive id and a name", i+1, path)
+		}
+		artists[i].DatesLocations.ID = artists[i].ID
+		artists[i].FirstAlbumDate = parseFirstAlbum(artists[i].FirstAlbum)
+	}
+	return artists, nil
+}
+
+// mergeArtists returns the API artists with the local ones merged in
+// a local artist replaces the API artist with the same id in place, the others are appended in their order
+func mergeArtists(api, local []Artists) []Artists {
+	byID := make(map[int]int, len(local))
+	for i, artist := range local {
+		byID[artist.ID] = i
+	}
+	merged := make([]Artists, 0, len(api)+len(local))
+	used := make(map[int]bool, len(local))
+	for _, artist := range api {
+		if i, found := byID[artist.ID]; found {
+			artist = local[i]
+			used[artist.ID] = true
+		}
+		merged = append(merged, artist)
+	}
+	for _, artist := range local {
+		if !used[artist.ID] {
+			merged = append(merged, artist)
+		}
+	}
+	return merged
+}
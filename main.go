@@ -1,25 +1,54 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"html/template"
+	"io/fs"
 	"log"
+	"log/slog"
+	"math/rand/v2"
 	"net/http"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"path"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
 )
 
 // Artists represents the artist data structure
 type Artists struct {
-	Image          string   `json:"image"`
-	ID             int      `json:"id"`
-	Name           string   `json:"name"`
-	Members        []string `json:"members"`
-	CreationDate   int      `json:"creationDate"`
-	FirstAlbum     string   `json:"firstAlbum"`
-	RelationsURL   string   `json:"relations"`
-	DatesLocations Relations
+	Image          string    `json:"image"`
+	ID             int       `json:"id"`
+	Name           string    `json:"name"`
+	Members        []string  `json:"members"`
+	CreationDate   int       `json:"creationDate"`
+	FirstAlbum     string    `json:"firstAlbum"`
+	FirstAlbumDate time.Time `json:"firstAlbumDate"`
+	RelationsURL   string    `json:"relations"`
+	Genre          string    `json:"genre"`
+	DatesLocations Relations `json:"datesLocations"`
+}
+
+// TotalConcerts returns the number of concert dates across all of the artist's locations
+func (a Artists) TotalConcerts() int {
+	total := 0
+	for _, dates := range a.DatesLocations.DatesLocations {
+		total += len(dates)
+	}
+	return total
+}
+
+// UniqueLocations returns the number of distinct locations the artist played at
+func (a Artists) UniqueLocations() int {
+	return len(a.DatesLocations.DatesLocations)
 }
 
 // Relations represents the concert dates and locations data
@@ -28,242 +57,431 @@ type Relations struct {
 	DatesLocations map[string][]string `json:"datesLocations"`
 }
 
-// RelationsResponse represents the API response structure
-type RelationsResponse struct {
-	Index []Relations `json:"index"`
+// ConcertRow represents a single concert date at a given location
+type ConcertRow struct {
+	Location string
+	Date     string
+}
+
+// ArtistPageData represents the data passed to the artist detail template
+type ArtistPageData struct {
+	BasePage
+	OG       OGMeta
+	Artist   Artists
+	Concerts []ConcertRow
+
+	// JSONLD is the schema.org MusicGroup of the artist, see BuildMusicGroupLD
+	JSONLD template.JS
 }
 
 // ErrorPage represents the data structure for error information
 type ErrorPage struct {
+	BasePage
 	Code    int
 	Message string
 	Is405   bool
 	Is404   bool
 	Is500   bool
 	Is403   bool
+	Is429   bool
 }
 
-// fetchData makes an HTTP GET request and decodes the JSON response
-func fetchData(url string, target interface{}) error {
-	response, err := http.Get(url)
-	if err != nil {
-		return fmt.Errorf("error making GET request: %w", err)
+// backoff limits used by fetchDataWithRetry
+const (
+	retryBaseDelay = 200 * time.Millisecond
+	retryMaxDelay  = 30 * time.Second
+)
+
+// fetchDataWithRetry calls fetcher.Fetch up to maxAttempts times, sleeping 200ms * 2^attempt (capped at 30s, ±10% jitter) between attempts
+// it stops early if ctx is done and returns the last error when every attempt failed
+// every attempt goes through breaker, ErrCircuitOpen is returned right away while it's open
+func fetchDataWithRetry(ctx context.Context, breaker *CircuitBreaker, fetcher Fetcher, url string, target interface{}, maxAttempts int) error {
+	var err error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		err = breaker.Do(func() error {
+			return fetcher.Fetch(ctx, url, target)
+		})
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, ErrCircuitOpen) {
+			return err
+		}
+		if attempt == maxAttempts-1 {
+			break
+		}
+
+		delay := retryBaseDelay << attempt
+		if delay > retryMaxDelay || delay <= 0 {
+			delay = retryMaxDelay
+		}
+		delay += time.Duration((rand.Float64()*0.2 - 0.1) * float64(delay))
+		log.Printf("Fetching %s failed (attempt %d/%d): %v, retrying in %v", url, attempt+1, maxAttempts, err, delay)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-time.After(delay):
+		}
 	}
-	defer response.Body.Close()
+	return err
+}
 
-	if response.StatusCode != http.StatusOK {
-		return fmt.Errorf("received non-200 response code: %d", response.StatusCode)
+// loadGenres reads the JSON file at path mapping artist ids to their genre
+func loadGenres(path string) (map[int]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading genres file: %w", err)
+	}
+	genres := make(map[int]string)
+	if err := json.Unmarshal(data, &genres); err != nil {
+		return nil, fmt.Errorf("error parsing genres file %s: %w", path, err)
 	}
-	return json.NewDecoder(response.Body).Decode(target)
+	return genres, nil
 }
 
-// handleError handles error responses consistently across handlers
-func handleError(w http.ResponseWriter, tmpl *template.Template, code int, message string) {
-	errorPage := ErrorPage{
-		Code:    code,
-		Message: message,
-		Is405:   code == http.StatusMethodNotAllowed,
-		Is404:   code == http.StatusNotFound,
-		Is500:   code == http.StatusInternalServerError,
-		Is403:   code == http.StatusForbidden,
-	}
-	w.WriteHeader(code)
-	if err := tmpl.Execute(w, errorPage); err != nil {
-		log.Printf("Error executing error template: %v", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+// findArtist returns the artist matching the given id and whether it was found
+func findArtist(artists []Artists, id int) (Artists, bool) {
+	for _, artist := range artists {
+		if artist.ID == id {
+			return artist, true
+		}
 	}
+	return Artists{}, false
 }
 
-// restrict is a middleware that restricts access to specific paths, /static and /images in this case
-// it takes a next(handlerfunc) and returns an http handler function that checks if our path is one of the restricted ones if so the file to parse and execute would be the 403 template and status is 403 forbidden
-// if the path doesn't figure in our restricted ones the handlerfunc is returned the usual way and the file to be parsed and executed would be determined
-func Restrict(next http.HandlerFunc) http.HandlerFunc {
-	templates := make(map[string]*template.Template)
-	templateFiles := map[string]string{
-		"index":  "templates/index.html",
-		"error":  "templates/error.html",
-		"about":  "templates/about.html",
-		"readme": "templates/readme.html",
+// searchArtists returns the artists whose name or one of its members contains the query, case insensitive
+func searchArtists(artists []Artists, query string) []Artists {
+	query = strings.ToLower(query)
+	var results []Artists
+	for _, artist := range artists {
+		if strings.Contains(strings.ToLower(artist.Name), query) {
+			results = append(results, artist)
+			continue
+		}
+		for _, member := range artist.Members {
+			if strings.Contains(strings.ToLower(member), query) {
+				results = append(results, artist)
+				break
+			}
+		}
 	}
+	return results
+}
 
-	for name, file := range templateFiles {
-		tmpl, err := template.ParseFiles(file)
-		if err != nil {
-			log.Fatalf("Error parsing template %s: %v", name, err)
+// concertRows expands the dates/locations map into rows sorted by location then date
+// dates are in the DD-MM-YYYY format so they're compared year first, then month, then day
+func concertRows(relation Relations) []ConcertRow {
+	var rows []ConcertRow
+	for location, dates := range relation.DatesLocations {
+		for _, date := range dates {
+			rows = append(rows, ConcertRow{Location: location, Date: date})
 		}
-		templates[name] = tmpl
 	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Location != rows[j].Location {
+			return rows[i].Location < rows[j].Location
+		}
+		return reverseDate(rows[i].Date) < reverseDate(rows[j].Date)
+	})
+	return rows
+}
 
-	return func(w http.ResponseWriter, r *http.Request) {
-		restrictedPaths := []string{"/static", "/assets", "/static/assets"}
-		for _, path := range restrictedPaths {
-			if r.URL.Path == path || r.URL.Path == path+"/" {
-				handleError(w, templates["error"], http.StatusForbidden, "Access Denied")
-				return
-			}
+// reverseDate turns a DD-MM-YYYY date into YYYY-MM-DD so it can be compared as a string
+func reverseDate(date string) string {
+	parts := strings.Split(date, "-")
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return strings.Join(parts, "-")
+}
+
+// requiredTemplates are the templates the handlers render, loading fails when one of them is missing
+var requiredTemplates = []string{"index", "about", "readme", "artist", "compare", "error"}
+
+// MissingTemplatesError is the error of TemplateHealth, Missing lists the required templates that aren't loaded
+type MissingTemplatesError struct {
+	Missing []string
+}
+
+func (e *MissingTemplatesError) Error() string {
+	return "missing templates: " + strings.Join(e.Missing, ", ")
+}
+
+// TemplateHealth checks that every required template is in templates, a nil template counts as missing
+// the error is a *MissingTemplatesError listing the missing ones in the order of required
+func TemplateHealth(templates map[string]*template.Template, required []string) error {
+	missing := []string{}
+	for _, name := range required {
+		if templates[name] == nil {
+			missing = append(missing, name)
 		}
-		next(w, r)
 	}
+	if len(missing) > 0 {
+		return &MissingTemplatesError{Missing: missing}
+	}
+	return nil
 }
 
-// this custom file sever allows to customize the errors in file serving
-// for example if a file we're trying to serve doesn't exist or if we don't have the necessary permissions
-// otherwise if a file doesn't exist for example a standard 404 error would be displayed
-// it takes the root as parameter and returns a handler
-// it uses os.Stat which returns meta data about a file on our os system
-// and returns an error which could be of two type
-// either the file doesn't exist or permissions denied
-// we're using serve file instead of fileserver cause it only serves one file instead of a whole directory
-func customFileServer(root string) http.Handler {
-	templates := make(map[string]*template.Template)
-	templateFiles := map[string]string{
-		"index":  "templates/index.html",
-		"error":  "templates/error.html",
-		"about":  "templates/about.html",
-		"readme": "templates/readme.html",
+// loadTemplates parses every .html file inside the dir directory of the embedded files, subdirectories included
+// the templates are keyed by their file name without the directory and extension, so templates/index.html is "index"
+// a new page only needs its file to be added, the templateFuncs helpers are available in all of them
+// every file is tried so the returned error lists all the templates that failed to parse
+func loadTemplates(dir string) (map[string]*template.Template, error) {
+	return parseTemplates(embeddedFS, dir)
+}
+
+// parseTemplates is loadTemplates reading the files from fsys, WatchTemplates uses it to read them from disk
+// files starting with "_" are partials, they aren't pages of their own but are parsed into every page so {{template}} finds them
+func parseTemplates(fsys fs.FS, dir string) (map[string]*template.Template, error) {
+	var pages, partials []string
+	err := fs.WalkDir(fsys, dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || path.Ext(file) != ".html" {
+			return nil
+		}
+		if strings.HasPrefix(path.Base(file), "_") {
+			partials = append(partials, file)
+		} else {
+			pages = append(pages, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing templates in %s: %w", dir, err)
 	}
 
-	for name, file := range templateFiles {
-		tmpl, err := template.ParseFiles(file)
+	templates := make(map[string]*template.Template)
+	var errs []error
+	for _, file := range pages {
+		name := strings.TrimSuffix(path.Base(file), ".html")
+		if _, found := templates[name]; found {
+			errs = append(errs, fmt.Errorf("template %s: %s has the same name as another template", name, file))
+			continue
+		}
+		tmpl, err := template.New(path.Base(file)).Funcs(templateFuncs).ParseFS(fsys, append([]string{file}, partials...)...)
 		if err != nil {
-			log.Fatalf("Error parsing template %s: %v", name, err)
+			errs = append(errs, fmt.Errorf("template %s: %w", name, err))
+			continue
 		}
 		templates[name] = tmpl
 	}
+	for _, name := range requiredTemplates {
+		if _, found := templates[name]; !found {
+			errs = append(errs, fmt.Errorf("template %s: %s.html is missing from %s", name, name, dir))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// this custom file sever allows to customize the errors in file serving
+// for example if a file we're trying to serve doesn't exist or if we're trying to list a directory
+// otherwise the standard plain text 404 and 403 errors of http.FileServer would be displayed
+// it serves the embedded files of the StaticDir of cfg and renders its errors with errorTmpl
+// it opens the file first through our staticFileSystem which returns an error of two types
+// either the file doesn't exist or it's a directory which we don't allow
+// once we know the file can be served it's handed to http.FileServer
+func customFileServer(cfg Config, errorTmpl *template.Template) (http.Handler, error) {
+	cfg = cfg.withDefaults()
+	files, err := newStaticFileSystem(cfg.StaticDir)
+	if err != nil {
+		return nil, fmt.Errorf("error opening static files: %w", err)
+	}
+	fileServer := http.FileServer(files)
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		path := filepath.Join(root, r.URL.Path)
-		if _, err := os.Stat(path); err != nil {
-			handleError(w, templates["error"], http.StatusNotFound, "Page not found")
+		file, err := files.Open(path.Clean("/" + r.URL.Path))
+		if errors.Is(err, fs.ErrPermission) {
+			WriteError(r.Context(), w, ForbiddenError("Access Denied"), errorTmpl)
 			return
 		}
-		http.ServeFile(w, r, path)
-	})
+		if err != nil {
+			WriteError(r.Context(), w, NotFoundError("Page not found"), errorTmpl)
+			return
+		}
+		file.Close()
+		fileServer.ServeHTTP(w, r)
+	}), nil
+}
+
+// exitOnTemplateError reports why the templates of dir couldn't be loaded and exits with status 1
+// every template that failed is listed on its own line so the broken or missing file is easy to spot
+func exitOnTemplateError(dir string, err error) {
+	log.Printf("Could not start the server, the templates in %s failed to load:", dir)
+	for _, line := range strings.Split(err.Error(), "\n") {
+		log.Printf("  %s", line)
+	}
+	os.Exit(1)
 }
 
 func main() {
-	// Parse templates
-	templates := make(map[string]*template.Template)
-	templateFiles := map[string]string{
-		"index":  "templates/index.html",
-		"error":  "templates/error.html",
-		"about":  "templates/about.html",
-		"readme": "templates/readme.html",
+	flags, err := parseFlags(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
 	}
+	if err != nil {
+		log.Fatalf("Error parsing flags: %v", err)
+	}
+	cfgFile, err := LoadConfig(flags.Config, flags)
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	cfg := *cfgFile
 
-	for name, file := range templateFiles {
-		tmpl, err := template.ParseFiles(file)
-		if err != nil {
-			log.Fatalf("Error parsing template %s: %v", name, err)
-		}
-		templates[name] = tmpl
+	// Parse templates
+	templates, err := loadTemplates(cfg.TemplateDir)
+	if err != nil {
+		exitOnTemplateError(cfg.TemplateDir, err)
 	}
 
-	// Fetch and prepare data
-	var artists []Artists
-	var relationsResponse RelationsResponse
+	metrics := NewMetrics()
 
-	// Fetch relations data
-	if err := fetchData("https://groupietrackers.herokuapp.com/api/relation", &relationsResponse); err != nil {
-		log.Printf("Error fetching relations: %v", err)
+	// Fetch and prepare data through the cache so the API is only hit once per TTL window
+	// the genres come from the local genres file since the API doesn't provide them
+	genres, err := loadGenres(cfg.GenresFile)
+	if err != nil {
+		log.Printf("Error loading genres: %v", err)
 	}
-
-	// Fetch artists data
-	if err := fetchData("https://groupietrackers.herokuapp.com/api/artists", &artists); err != nil {
-		log.Printf("Error fetching artists: %v", err)
+	slos, err := loadSLOs(cfg.SLOFile)
+	if err != nil {
+		log.Printf("Error loading SLOs: %v", err)
+	}
+	client := NewHTTPClient(cfg)
+	metrics.InstrumentClient(client)
+	loader := newArtistLoader(cfg, metrics, genres, HTTPFetcher{Client: client})
+	loadCtx, cancelLoad := context.WithTimeout(context.Background(), time.Minute)
+	artists, err := loader.LoadFirst(loadCtx)
+	cancelLoad()
+	if err != nil {
+		log.Fatalf("Error fetching data: %v", err)
 	}
 
-	// Map relations to artists
-	relationsMap := make(map[int]Relations)
-	for _, relation := range relationsResponse.Index {
-		relationsMap[relation.ID] = relation
+	app := &App{
+		Templates:   templates,
+		Config:      cfg,
+		Genres:      genres,
+		SLOs:        slos,
+		Metrics:     metrics,
+		StartTime:   time.Now(),
+		loadArtists: loader.Load,
+		relations:   loader.relations,
+		breaker:     loader.breaker,
+		client:      client,
+		geo:         NewNominatimClient(NewHTTPClient(cfg), cfg.AppName),
 	}
-	for i := range artists {
-		relation, found := relationsMap[artists[i].ID]
-		if found {
-			artists[i].DatesLocations = relation
+	// the local artists like The Weeknd aren't part of the API, they're the first custom artists
+	local, err := LoadLocalArtists(cfg.LocalArtistsFile)
+	if err != nil {
+		log.Printf("Error loading local artists: %v", err)
+	}
+	for i := range local {
+		if local[i].Genre == "" {
+			local[i].Genre = genres[local[i].ID]
 		}
 	}
-
-	// Add The Weeknd to artists
-	theWeeknd := Artists{
-		Image:        "/static/assets/xo.jpeg",
-		ID:           54,
-		Name:         "The Weeknd",
-		Members:      []string{"Abel Tesfaye"},
-		CreationDate: 2009,
-		FirstAlbum:   "House of baloons",
-		DatesLocations: Relations{
-			ID: 54,
-			DatesLocations: map[string][]string{
-				"new_york_usa":   {"27-11-2016", "26-11-2016"},
-				"toronto_canada": {"05-09-2016", "04-09-2016"},
-				"oujda_morocco":  {"02-12-2016", "01-12-2016"},
-			},
-		},
-	}
-	artists = append([]Artists{theWeeknd}, artists...)
-
-	// Define route handlers
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != "/" {
-			handleError(w, templates["error"], http.StatusNotFound, "Page not found")
-			return
+	app.customArtists = local
+	if !cfg.Dev {
+		app.pages = NewFileCache(cfg.PageCacheSize)
+	}
+	app.hub = NewHub(maxWSClients)
+	app.setArtists(artists)
+	if cfg.Dev {
+		if err := WatchTemplates(cfg.TemplateDir, &app.Templates, &app.templatesMu); err != nil {
+			log.Printf("Error starting the template hot reload: %v", err)
 		}
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		app.checkAPIStatus(ctx)
+	}()
+
+	// Keep the artist data current, the refresh stops when the server shuts down
+	refreshCtx, stopRefresh := context.WithCancel(context.Background())
+	defer stopRefresh()
+	loadRemainingRelations(refreshCtx, app)
+	StartDataRefresh(refreshCtx, app, time.Duration(cfg.RefreshInterval))
+	go app.hub.Run(refreshCtx)
+
+	// LoadConfig already checked the level
+	level, _ := parseLogLevel(cfg.LogLevel)
+	logger := slog.New(requestIDHandler{slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level})})
+	slog.SetDefault(logger)
+
+	router, err := buildRouter(refreshCtx, app, logger)
+	if err != nil {
+		log.Fatalf("Error setting up the routes: %v", err)
+	}
 
-		if r.Method != http.MethodGet {
-			handleError(w, templates["error"], http.StatusMethodNotAllowed, "Method not allowed")
-			return
-		}
+	// Start server in a goroutine so main can wait for a shutdown signal
+	server := &http.Server{
+		Addr:    cfg.Addr(),
+		Handler: router,
+	}
 
-		if err := templates["index"].Execute(w, artists); err != nil {
-			log.Printf("Error executing index template: %v", err)
-			handleError(w, templates["error"], http.StatusInternalServerError, "Internal server error")
+	// With TLS the certificates come either from the configured files or from Let's Encrypt
+	// and a second server on port 80 redirects plain HTTP to HTTPS
+	var redirectServer *http.Server
+	if cfg.TLSEnabled() {
+		var manager *autocert.Manager
+		if cfg.TLSCertFile == autoCert {
+			manager, err = cfg.autocertManager()
+			if err != nil {
+				log.Fatalf("Error setting up Let's Encrypt: %v", err)
+			}
+			server.TLSConfig = manager.TLSConfig()
 		}
-	})
 
-	http.HandleFunc("/about", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != "/about" {
-			handleError(w, templates["error"], http.StatusNotFound, "Page not found")
-			return
-		}
+		redirectServer = newRedirectServer(cfg, manager, router)
+		go func() {
+			if err := redirectServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("HTTP redirect server failed: %v", err)
+			}
+		}()
+	}
 
-		if r.Method != http.MethodGet {
-			handleError(w, templates["error"], http.StatusMethodNotAllowed, "Method not allowed")
-			return
+	go func() {
+		var err error
+		if cfg.TLSEnabled() {
+			fmt.Printf("Server started at https://localhost:%s\n", cfg.Port)
+			certFile, keyFile := cfg.TLSCertFile, cfg.TLSKeyFile
+			if certFile == autoCert {
+				// the certificates come from server.TLSConfig
+				certFile, keyFile = "", ""
+			}
+			err = server.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			fmt.Printf("Server started at http://localhost:%s\n", cfg.Port)
+			err = server.ListenAndServe()
 		}
-
-		if err := templates["about"].Execute(w, nil); err != nil {
-			log.Printf("Error executing about template: %v", err)
-			handleError(w, templates["error"], http.StatusInternalServerError, "Internal server error")
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server failed to start: %v", err)
 		}
-	})
+	}()
 
-	http.HandleFunc("/readme", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != "/readme" {
-			handleError(w, templates["error"], http.StatusNotFound, "Page not found")
-			return
-		}
+	// Wait for SIGINT or SIGTERM then let in-flight requests complete before exiting
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
 
-		if r.Method != http.MethodGet {
-			handleError(w, templates["error"], http.StatusMethodNotAllowed, "Method not allowed")
-			return
-		}
+	log.Println("server shutting down")
+	stopRefresh()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-		if err := templates["readme"].Execute(w, nil); err != nil {
-			log.Printf("Error executing readme template: %v", err)
-			handleError(w, templates["error"], http.StatusInternalServerError, "Internal server error")
+	if redirectServer != nil {
+		if err := redirectServer.Shutdown(ctx); err != nil {
+			log.Printf("HTTP redirect server forced to shut down: %v", err)
 		}
-	})
-
-	// Serve static files
-	http.Handle("/static/", http.StripPrefix("/static/", customFileServer("templates")))
-	http.Handle("/assets/", customFileServer("templates"))
-
-	// Start server
-	port := ":8080"
-	fmt.Printf("Server started at http://localhost%s\n", port)
-	if err := http.ListenAndServe(port, Restrict(http.DefaultServeMux.ServeHTTP)); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
 	}
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Server forced to shut down: %v", err)
+		return
+	}
+	log.Println("server exited cleanly")
 }
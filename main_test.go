@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeTemplates writes the files to dir, keyed by their path relative to it
+func writeTemplates(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// dummyTemplates returns a file for every required template, each calling the footer partial
+func dummyTemplates() map[string]string {
+	files := map[string]string{"_footer.html": `{{define "footer"}}footer{{end}}`}
+	for _, name := range requiredTemplates {
+		files[name+".html"] = name + ` {{template "footer"}}`
+	}
+	return files
+}
+
+func TestParseTemplates(t *testing.T) {
+	dir := t.TempDir()
+	files := dummyTemplates()
+	files["extra/stats.html"] = `{{formatLocation "paris-france"}}`
+	files["notes.txt"] = "not a template"
+	writeTemplates(t, filepath.Join(dir, "templates"), files)
+
+	templates, err := parseTemplates(os.DirFS(dir), "templates")
+	if err != nil {
+		t.Fatalf("parseTemplates: %v", err)
+	}
+	var names []string
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	want := append([]string{"stats"}, requiredTemplates...)
+	sort.Strings(want)
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("keys = %v, want %v", names, want)
+	}
+
+	var out strings.Builder
+	if err := templates["index"].Execute(&out, nil); err != nil {
+		t.Fatalf("executing index: %v", err)
+	}
+	if out.String() != "index footer" {
+		t.Errorf("index = %q, want %q", out.String(), "index footer")
+	}
+}
+
+func TestParseTemplatesErrors(t *testing.T) {
+	dir := t.TempDir()
+	files := dummyTemplates()
+	delete(files, "about.html")
+	files["artist.html"] = `{{if}}`
+	writeTemplates(t, filepath.Join(dir, "templates"), files)
+
+	templates, err := parseTemplates(os.DirFS(dir), "templates")
+	if err == nil {
+		t.Fatalf("parseTemplates returned %d templates and no error", len(templates))
+	}
+	// every failure is reported, not just the first
+	for _, want := range []string{"template about", "template artist"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't mention %q", err, want)
+		}
+	}
+}
+
+func TestLoadTemplates(t *testing.T) {
+	templates, err := loadTemplates("templates")
+	if err != nil {
+		t.Fatalf("loadTemplates: %v", err)
+	}
+	if err := TemplateHealth(templates, requiredTemplates); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// PWAManifest is the web app manifest served at /manifest.json, it lets browsers install the site as an app
+type PWAManifest struct {
+	Name            string         `json:"name"`
+	ShortName       string         `json:"short_name"`
+	StartURL        string         `json:"start_url"`
+	Display         string         `json:"display"`
+	BackgroundColor string         `json:"background_color"`
+	ThemeColor      string         `json:"theme_color"`
+	Icons           []ManifestIcon `json:"icons"`
+}
+
+// ManifestIcon is one of the icons listed in the manifest
+type ManifestIcon struct {
+	Src   string `json:"src"`
+	Sizes string `json:"sizes"`
+	Type  string `json:"type"`
+}
+
+// ManifestHandler serves the web app manifest, its name and colors come from the config
+func (a *App) ManifestHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	cfg := a.Config.withDefaults()
+	manifest := PWAManifest{
+		Name:            cfg.AppName,
+		ShortName:       cfg.AppShortName,
+		StartURL:        "/",
+		Display:         "standalone",
+		BackgroundColor: cfg.BackgroundColor,
+		ThemeColor:      cfg.ThemeColor,
+		Icons: []ManifestIcon{
+			{Src: "/favicon.ico", Sizes: "32x32", Type: "image/x-icon"},
+		},
+	}
+	body, err := json.Marshal(manifest)
+	if err != nil {
+		writeJSONError(w, r, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.Header().Set("Content-Type", "application/manifest+json")
+	w.Write(body)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics holds the Prometheus registry and the collectors the server reports
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	requests      *prometheus.CounterVec
+	duration      *prometheus.HistogramVec
+	artistsLoaded prometheus.Gauge
+	fetchErrors   prometheus.Counter
+
+	clientInFlight prometheus.Gauge
+	clientErrors   prometheus.Counter
+}
+
+// NewMetrics creates the collectors and registers them, along with the Go and process collectors, on a new registry
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Number of HTTP requests served, by method, path and status.",
+		}, []string{"method", "path", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Time taken to serve HTTP requests, by method and path.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "path"}),
+		artistsLoaded: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "artists_loaded_total",
+			Help: "Number of artists currently loaded in memory.",
+		}),
+		fetchErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "api_fetch_errors_total",
+			Help: "Number of failed fetches from the Groupie Trackers API.",
+		}),
+		clientInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "api_client_in_flight_requests",
+			Help: "Number of requests to the Groupie Trackers API currently in flight.",
+		}),
+		clientErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "api_client_errors_total",
+			Help: "Number of requests to the Groupie Trackers API that failed before getting a response.",
+		}),
+	}
+	m.Registry.MustRegister(
+		m.requests,
+		m.duration,
+		m.artistsLoaded,
+		m.fetchErrors,
+		m.clientInFlight,
+		m.clientErrors,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler serves the registered metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{})
+}
+
+// Middleware counts and times every request served by next
+// the requests are labelled with the pattern of the Router route serving them, see metricsPath
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		pattern := new(string)
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), routePatternKey, pattern)))
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		path := metricsPath(*pattern, rec.status)
+		m.requests.WithLabelValues(r.Method, path, strconv.Itoa(rec.status)).Inc()
+		m.duration.WithLabelValues(r.Method, path).Observe(time.Since(start).Seconds())
+	})
+}
+
+// metricsPath turns the matched route pattern into a route label so the number of label values stays bounded
+// requests no route matched and 404s, even under a catch-all pattern like "/", are grouped under "unmatched"
+func metricsPath(pattern string, status int) string {
+	if pattern == "" || status == http.StatusNotFound {
+		return "unmatched"
+	}
+	return pattern
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// visitorTTL is how long an IP can go without a request before its limiter is pruned
+const visitorTTL = 5 * time.Minute
+
+// visitor holds the token bucket of a single IP and the last time it was seen
+type visitor struct {
+	limiter  *rate.Limiter
+	lastSeen atomic.Int64
+}
+
+// clientIP returns the remote IP of the request without the port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// RateLimit is a middleware that gives every IP a token bucket of requestsPerSecond tokens with the given burst
+// once the bucket is empty the request is answered with 429 and the error template
+// a background goroutine prunes IPs that haven't been seen in the last 5 minutes so the map doesn't grow forever
+// it stops once ctx is done
+func RateLimit(ctx context.Context, requestsPerSecond float64, burst int, errorTmpl *template.Template) func(http.Handler) http.Handler {
+	var visitors sync.Map
+
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			visitors.Range(func(key, value interface{}) bool {
+				if time.Since(time.Unix(0, value.(*visitor).lastSeen.Load())) > visitorTTL {
+					visitors.Delete(key)
+				}
+				return true
+			})
+		}
+	}()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			value, _ := visitors.LoadOrStore(clientIP(r), &visitor{
+				limiter: rate.NewLimiter(rate.Limit(requestsPerSecond), burst),
+			})
+			v := value.(*visitor)
+			v.lastSeen.Store(time.Now().UnixNano())
+
+			if !v.limiter.Allow() {
+				WriteError(r.Context(), w, TooManyRequestsError(), errorTmpl)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// NewRestrictMiddleware returns a middleware answering 403 with the error template for the restricted paths
+// a path matches with or without its trailing slash, so "/static" restricts both /static and /static/
+// the files below a restricted path, like /static/style.css, are still served
+func NewRestrictMiddleware(restrictedPaths []string, errorTmpl *template.Template) func(http.Handler) http.Handler {
+	restricted := make(map[string]bool, len(restrictedPaths))
+	for _, path := range restrictedPaths {
+		restricted[strings.TrimSuffix(path, "/")] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if path := strings.TrimSuffix(r.URL.Path, "/"); path != "" && restricted[path] {
+				WriteError(r.Context(), w, ForbiddenError("Access Denied"), errorTmpl)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// BasicAuth is a middleware that only lets through the requests carrying the given HTTP Basic Auth credentials
+// the others are answered with a JSON 401, every request is refused when user or password is empty
+func BasicAuth(user, password string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotUser, gotPassword, ok := r.BasicAuth()
+			// both are compared in constant time so the response time doesn't leak how much of them matched
+			userMatch := subtle.ConstantTimeCompare([]byte(gotUser), []byte(user)) == 1
+			passwordMatch := subtle.ConstantTimeCompare([]byte(gotPassword), []byte(password)) == 1
+			if !ok || user == "" || password == "" || !userMatch || !passwordMatch {
+				w.Header().Set("WWW-Authenticate", `Basic realm="admin", charset="UTF-8"`)
+				writeJSONError(w, r, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// body size limits of MaxBodySize, the JSON API and admin routes take an artist at most
+// while the search forms only ever send a few fields
+const (
+	maxJSONBodySize   = 1 << 20
+	maxSearchBodySize = 10 << 10
+)
+
+// MaxBodySize is a middleware that caps request bodies at maxBytes
+// a body announcing a bigger Content-Length is answered with a JSON 413 right away,
+// the others are wrapped in http.MaxBytesReader so a handler reading past the limit gets an error, see isBodyTooLarge
+func MaxBodySize(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxBytes {
+				writeJSONError(w, r, http.StatusRequestEntityTooLarge, "Request body too large")
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isBodyTooLarge reports whether err comes from reading past the limit of MaxBodySize
+func isBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+// robotsTag returns a middleware setting the X-Robots-Tag header of every response to value
+func robotsTag(value string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Robots-Tag", value)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// NoIndex is the middleware of the /api/ and /export/ routes, it asks search engines not to index their responses
+// nor follow their links with an X-Robots-Tag header
+func NoIndex(next http.Handler) http.Handler {
+	return robotsTag("noindex, nofollow")(next)
+}
+
+// RequireJSON is the middleware of the /api/ routes, POST, PUT and PATCH requests must send a JSON body
+// the others get a JSON 415, every response is marked nosniff so browsers never guess another content type
+func RequireJSON(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			if !strings.HasPrefix(strings.ToLower(r.Header.Get("Content-Type")), "application/json") {
+				writeJSONError(w, r, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CORS is a middleware that sets Access-Control-Allow-Origin for the origins in the allow-list, "*" allows any origin
+// preflight OPTIONS requests are answered with the allowed methods and headers and short-circuited with 204
+func CORS(origins []string) func(http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		allowed[origin] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			w.Header().Add("Vary", "Origin")
+			if origin != "" {
+				if allowed["*"] {
+					w.Header().Set("Access-Control-Allow-Origin", "*")
+				} else if allowed[origin] {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+				}
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// contextKey is the type of the keys this package stores in request contexts
+type contextKey int
+
+// requestIDKey is the context key holding the id of the current request
+// routePatternKey holds the *string the Router sets to the pattern of the route serving the request
+const (
+	requestIDKey contextKey = iota
+	routePatternKey
+)
+
+// requestIDFromContext returns the request id stored in ctx, or an empty string if there is none
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// maxRequestIDLength bounds the X-Request-ID values accepted from clients
+const maxRequestIDLength = 128
+
+// newUUID returns a random version 4 UUID
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// validRequestID reports whether a client supplied request id is short and only made of printable ASCII
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestID is a middleware that gives every request an id, reused from the X-Request-ID header when the client sent one
+// the id is stored in the request context and sent back in the X-Request-ID response header
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID(id) {
+			id = newUUID()
+		}
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+	})
+}
+
+// requestIDHandler is a slog.Handler adding the request id found in the context to every log entry
+type requestIDHandler struct {
+	slog.Handler
+}
+
+// Handle adds the request_id attribute when ctx carries one
+func (h requestIDHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := requestIDFromContext(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs keeps the request id handling on the derived handler
+func (h requestIDHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return requestIDHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup keeps the request id handling on the derived handler
+func (h requestIDHandler) WithGroup(name string) slog.Handler {
+	return requestIDHandler{h.Handler.WithGroup(name)}
+}
+
+// statusRecorder wraps a ResponseWriter to capture the status code written by the handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status code before passing it on
+func (rec *statusRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+// Write records an implicit 200 if the handler never called WriteHeader
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+// Hijack hands the connection over to the handler, like a WebSocket upgrade, recording it as a 101
+func (rec *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buf, err := http.NewResponseController(rec.ResponseWriter).Hijack()
+	if err == nil && rec.status == 0 {
+		rec.status = http.StatusSwitchingProtocols
+	}
+	return conn, buf, err
+}
+
+// Unwrap gives http.ResponseController access to the underlying ResponseWriter
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// RequestLogger is a middleware that logs every request as a single structured line once it has been served
+// the request id is the one set by the RequestID middleware
+// requests slower than the SLO of their route are logged again as a warning with slo_breached=true
+// requests to one of the skipPaths are served without being logged
+func RequestLogger(logger *slog.Logger, slos []SLOConfig, skipPaths ...string) func(http.Handler) http.Handler {
+	skip := make(map[string]bool, len(skipPaths))
+	for _, path := range skipPaths {
+		skip[path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w}
+			// the entry is written by a defer so a panicking request is logged as the 500 Recovery turns it into
+			defer func() {
+				err := recover()
+				if err != nil && err != http.ErrAbortHandler && rec.status == 0 {
+					rec.status = http.StatusInternalServerError
+				}
+				logRequest(logger, slos, r, rec, time.Since(start), skip[r.URL.Path])
+				if err != nil {
+					panic(err)
+				}
+			}()
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+// logRequest writes the access log entry of r and the SLO warning when it was slower than its SLO, unless skipped
+func logRequest(logger *slog.Logger, slos []SLOConfig, r *http.Request, rec *statusRecorder, latency time.Duration, skipped bool) {
+	if skipped {
+		return
+	}
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+
+	logger.Info("request",
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.Int("status", rec.status),
+		slog.Float64("latency_ms", float64(latency.Microseconds())/1000),
+		slog.String("remote_addr", r.RemoteAddr),
+		slog.String("request_id", requestIDFromContext(r.Context())),
+	)
+	// hijacked connections and event streams stay open as long as the client does, their latency isn't a response time
+	streaming := rec.status == http.StatusSwitchingProtocols || rec.Header().Get("Content-Type") == "text/event-stream"
+	if slo := matchRouteSLO(r.URL.Path, slos); latency > slo && !streaming {
+		logger.Warn("request slower than its SLO",
+			slog.Bool("slo_breached", true),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Float64("latency_ms", float64(latency.Microseconds())/1000),
+			slog.Float64("slo_ms", float64(slo.Microseconds())/1000),
+			slog.String("request_id", requestIDFromContext(r.Context())),
+		)
+	}
+}
+
+// Recovery is a middleware that recovers from panics in the handlers below it, it's the outermost one
+// the stack trace is logged and the error template is rendered with a 500 instead of crashing the server
+// http.ErrAbortHandler is re-panicked since net/http uses it on purpose to abort a response
+// the request id is taken back from the X-Request-ID response header set by RequestID since the context carrying it is gone
+func Recovery(tmpl *template.Template) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				err := recover()
+				if err == nil {
+					return
+				}
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+				ctx := r.Context()
+				if id := w.Header().Get("X-Request-ID"); id != "" {
+					ctx = context.WithValue(ctx, requestIDKey, id)
+				}
+				slog.ErrorContext(ctx, "panic recovered",
+					slog.Any("error", err),
+					slog.String("path", r.URL.Path),
+					slog.String("stack", string(debug.Stack())),
+				)
+				WriteError(ctx, w, InternalError(fmt.Errorf("panic: %v", err)), tmpl)
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SecureHeaders is a middleware that sets the security related response headers on every response
+// csp is used as the Content-Security-Policy, in dev mode the middleware does nothing so localhost reloads aren't blocked
+func SecureHeaders(csp string, dev bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if dev {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			w.Header().Set("X-Frame-Options", "DENY")
+			w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
+			w.Header().Set("Content-Security-Policy", csp)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
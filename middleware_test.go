@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testErrorTemplate stands in for the error template in the middleware tests
+var testErrorTemplate = template.Must(template.New("error").Parse(`{{.Code}} {{.Message}}`))
+
+// okHandler answers every request with a 200
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func TestNewRestrictMiddleware(t *testing.T) {
+	handler := NewRestrictMiddleware([]string{"/static", "/assets/", "/static/assets"}, testErrorTemplate)(okHandler)
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/static", http.StatusForbidden},
+		{"/static/", http.StatusForbidden},
+		{"/assets", http.StatusForbidden},
+		{"/assets/", http.StatusForbidden},
+		{"/static/assets", http.StatusForbidden},
+		{"/static/assets/", http.StatusForbidden},
+		{"/static/style.css", http.StatusOK},
+		{"/static/assets/logo.png", http.StatusOK},
+		{"/staticfiles", http.StatusOK},
+		{"/", http.StatusOK},
+		{"/about", http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := serve(handler, http.MethodGet, tt.path, nil)
+		if w.Code != tt.want {
+			t.Errorf("GET %s = %d, want %d", tt.path, w.Code, tt.want)
+		}
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	const limit = 16
+	// readAll reads the whole body like a JSON decoder would, answering 413 past the limit
+	readAll := func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			if isBodyTooLarge(err) {
+				writeJSONError(w, r, http.StatusRequestEntityTooLarge, "Request body too large")
+				return
+			}
+			writeJSONError(w, r, http.StatusBadRequest, "Invalid body")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	handler := MaxBodySize(limit)(http.HandlerFunc(readAll))
+
+	tests := []struct {
+		name string
+		body string
+		// chunked bodies don't announce their length so only reading them finds out they're too large
+		chunked bool
+		want    int
+	}{
+		{"at the limit", strings.Repeat("a", limit), false, http.StatusOK},
+		{"over the limit", strings.Repeat("a", limit+1), false, http.StatusRequestEntityTooLarge},
+		{"chunked at the limit", strings.Repeat("a", limit), true, http.StatusOK},
+		{"chunked over the limit", strings.Repeat("a", limit+1), true, http.StatusRequestEntityTooLarge},
+		{"empty", "", false, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/admin/artists", strings.NewReader(tt.body))
+			if tt.chunked {
+				r.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if tt.want == http.StatusRequestEntityTooLarge {
+				envelope := decodeEnvelope[any](t, w)
+				if envelope.Error == nil || envelope.Error.Code != http.StatusRequestEntityTooLarge {
+					t.Errorf("error = %+v, want a 413", envelope.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestMaxBodySizeRoutes(t *testing.T) {
+	app := newTestApp(t, testArtists())
+	app.Config.AdminUser, app.Config.AdminPassword = "admin", "secret"
+	handler := newTestServer(t, app)
+
+	// the admin artist is a JSON document padded with spaces to exactly maxJSONBodySize bytes, then one byte more
+	artist := `{"id": 100, "name": "The Weeknd", "creationDate": 2010, "firstAlbum": "2011"}`
+	atLimit := artist + strings.Repeat(" ", maxJSONBodySize-len(artist))
+	if w := postArtist(handler, "admin", "secret", atLimit); w.Code != http.StatusCreated {
+		t.Errorf("body of maxJSONBodySize bytes = %d, want %d", w.Code, http.StatusCreated)
+	}
+	if w := postArtist(handler, "admin", "secret", atLimit+" "); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("body of maxJSONBodySize+1 bytes = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+
+	// the search forms take far less
+	r := httptest.NewRequest(http.MethodGet, "/search?q=queen", strings.NewReader(strings.Repeat("a", maxSearchBodySize+1)))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("search body of maxSearchBodySize+1 bytes = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestRateLimitStopsPruning(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := 0; i < 3; i++ {
+		RateLimit(ctx, 1, 1, testErrorTemplate)
+	}
+
+	// the 3 pruning goroutines exit once ctx is done
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if runtime.NumGoroutine() > before {
+		t.Errorf("%d goroutines once ctx is done, want %d", runtime.NumGoroutine(), before)
+	}
+}
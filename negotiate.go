@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// negotiateFormat returns "json" when r asks for JSON and "html" otherwise
+// an explicit ?format=json or ?format=html wins over the Accept header
+func negotiateFormat(r *http.Request) string {
+	switch r.URL.Query().Get("format") {
+	case "json":
+		return "json"
+	case "html":
+		return "html"
+	}
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		return "json"
+	}
+	return "html"
+}
+
+// renderPage answers with data as JSON when negotiateFormat asks for it, otherwise renders it with the name template
+// cached pages go through the page cache, see renderCachedPage, except for the HTMX requests, see renderFragment
+func (a *App) renderPage(w http.ResponseWriter, r *http.Request, name string, data interface{}, cached bool) {
+	if negotiateFormat(r) == "json" {
+		writeJSON(w, http.StatusOK, data)
+		return
+	}
+	if isHTMXRequest(r) {
+		a.renderFragment(w, r, a.template(name), data)
+		return
+	}
+	if cached {
+		a.renderCachedPage(w, r, name, data)
+		return
+	}
+	a.renderTemplate(r.Context(), w, a.template(name), data)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// OGMeta is the Open Graph and Twitter Card data of a page, rendered by the og_meta partial
+type OGMeta struct {
+	Title       string
+	Description string
+	Image       string
+	URL         string
+	SiteName    string
+}
+
+// ogDescriptionLength is the length social networks show of a description before cutting it
+const ogDescriptionLength = 160
+
+// ogDescription describes artist in a sentence for link previews, e.g. "Queen, formed in 1970, with Freddie Mercury and Brian May"
+func ogDescription(artist Artists) string {
+	var b strings.Builder
+	b.WriteString(artist.Name)
+	if artist.CreationDate > 0 {
+		b.WriteString(", formed in " + strconv.Itoa(artist.CreationDate))
+	}
+	switch len(artist.Members) {
+	case 0:
+	case 1:
+		b.WriteString(", with " + artist.Members[0])
+	default:
+		last := len(artist.Members) - 1
+		b.WriteString(", with " + strings.Join(artist.Members[:last], ", ") + " and " + artist.Members[last])
+	}
+	return truncate(ogDescriptionLength, b.String())
+}
+
+// artistOGMeta returns the link preview data of the detail page of artist, its URLs are absolute ones on PublicURL
+func (a *App) artistOGMeta(artist Artists) OGMeta {
+	cfg := a.Config.withDefaults()
+	base := strings.TrimSuffix(cfg.PublicURL, "/")
+	return OGMeta{
+		Title:       artist.Name,
+		Description: ogDescription(artist),
+		Image:       base + (&url.URL{Path: artistImage(artist.Name)}).EscapedPath(),
+		URL:         base + "/artist/" + strconv.Itoa(artist.ID),
+		SiteName:    cfg.AppName,
+	}
+}
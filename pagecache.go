@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+)
+
+// defaultPageCacheSize is the number of rendered pages kept by the page cache
+const defaultPageCacheSize = 100
+
+// FileCache keeps the last rendered pages in memory, evicting the least recently used one once it's full
+// the entries are keyed by the request URL and the data version so a data refresh never serves a stale page
+type FileCache struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	version string
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// fileCacheEntry is the value of the elements of FileCache.order
+type fileCacheEntry struct {
+	key  string
+	body []byte
+}
+
+// NewFileCache returns a FileCache holding up to maxEntries pages
+func NewFileCache(maxEntries int) *FileCache {
+	if maxEntries < 1 {
+		maxEntries = defaultPageCacheSize
+	}
+	return &FileCache{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// key hashes the URL with the current data version, fc.mu must be held
+func (fc *FileCache) key(url string) string {
+	sum := sha256.Sum256([]byte(fc.version + "\x00" + url))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns the cached page of url and marks it as recently used
+func (fc *FileCache) Get(url string) ([]byte, bool) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	element, found := fc.entries[fc.key(url)]
+	if !found {
+		return nil, false
+	}
+	fc.order.MoveToFront(element)
+	return element.Value.(*fileCacheEntry).body, true
+}
+
+// Put caches the page of url, evicting the least recently used page when the cache is full
+func (fc *FileCache) Put(url string, body []byte) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	key := fc.key(url)
+	if element, found := fc.entries[key]; found {
+		element.Value.(*fileCacheEntry).body = body
+		fc.order.MoveToFront(element)
+		return
+	}
+	fc.entries[key] = fc.order.PushFront(&fileCacheEntry{key: key, body: body})
+	for fc.order.Len() > fc.maxEntries {
+		oldest := fc.order.Back()
+		fc.order.Remove(oldest)
+		delete(fc.entries, oldest.Value.(*fileCacheEntry).key)
+	}
+}
+
+// SetVersion empties the cache and keys the next pages with version, it's called when the data is refreshed
+// a nil FileCache ignores it
+func (fc *FileCache) SetVersion(version string) {
+	if fc == nil {
+		return
+	}
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.version = version
+	fc.order.Init()
+	fc.entries = make(map[string]*list.Element)
+}
+
+// Clear empties the cache while keeping its version, a nil FileCache ignores it
+func (fc *FileCache) Clear() {
+	if fc == nil {
+		return
+	}
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.order.Init()
+	fc.entries = make(map[string]*list.Element)
+}
+
+// ServeFromCache writes the cached HTML page of r, rendering and caching it with render when it isn't cached yet
+// nothing is written when render fails, its error is returned so the caller can answer with the error page
+// a nil fc renders every request
+func ServeFromCache(w http.ResponseWriter, r *http.Request, fc *FileCache, render func() ([]byte, error)) error {
+	url := r.URL.RequestURI()
+	body, found := []byte(nil), false
+	if fc != nil {
+		body, found = fc.Get(url)
+	}
+	if !found {
+		var err error
+		body, err = render()
+		if err != nil {
+			return err
+		}
+		if fc != nil {
+			fc.Put(url, body)
+		}
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(body)
+	return nil
+}
+
+// renderCachedPage serves the name template rendered with data through the page cache
+func (a *App) renderCachedPage(w http.ResponseWriter, r *http.Request, name string, data interface{}) {
+	tmpl := a.template(name)
+	err := ServeFromCache(w, r, a.pages, func() ([]byte, error) {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	})
+	if err != nil {
+		WriteError(r.Context(), w, InternalError(err), a.template("error"))
+	}
+}
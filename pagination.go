@@ -0,0 +1,117 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// default values for the page and limit query parameters
+const (
+	defaultPage  = 1
+	defaultLimit = 20
+)
+
+// PagedArtists represents one page of artists along with what's needed to render the pagination links
+type PagedArtists struct {
+	Artists    []Artists
+	Page       int
+	Limit      int
+	TotalPages int
+	HasNext    bool
+	HasPrev    bool
+
+	// Query is the query string of the listing, kept in the pagination links so the filters survive a page change
+	Query url.Values
+}
+
+// IndexPageData represents the data passed to the index template, a page of artists plus the active filters
+type IndexPageData struct {
+	BasePage
+	PagedArtists
+	Country string
+	Decade  string
+	Decades []string
+
+	// FilterSummary describes the filters of an advanced search, empty for the other listings
+	FilterSummary string
+}
+
+// NextPage returns the number of the page after the current one
+func (p PagedArtists) NextPage() int {
+	return p.Page + 1
+}
+
+// PrevPage returns the number of the page before the current one
+func (p PagedArtists) PrevPage() int {
+	return p.Page - 1
+}
+
+// withQuery returns p with the query string its pagination links keep, see nextPage and prevPage
+func (p PagedArtists) withQuery(query url.Values) PagedArtists {
+	p.Query = query
+	return p
+}
+
+// QueryParams returns values encoded as a query string with key set to value, values itself isn't modified
+func QueryParams(values url.Values, key, value string) string {
+	params := make(url.Values, len(values)+1)
+	for k, v := range values {
+		params[k] = v
+	}
+	params.Set(key, value)
+	return params.Encode()
+}
+
+// nextPage is the template function returning the URL of the page after p with the other query parameters kept
+func nextPage(p PagedArtists) string {
+	return "?" + QueryParams(p.Query, "page", strconv.Itoa(p.NextPage()))
+}
+
+// prevPage is the template function returning the URL of the page before p with the other query parameters kept
+func prevPage(p PagedArtists) string {
+	return "?" + QueryParams(p.Query, "page", strconv.Itoa(p.PrevPage()))
+}
+
+// paginate slices artists into the requested page, falling back to page 1 when the page is out of range
+func paginate(artists []Artists, page, limit int) PagedArtists {
+	if limit < 1 {
+		limit = defaultLimit
+	}
+
+	totalPages := (len(artists) + limit - 1) / limit
+	if totalPages < 1 {
+		totalPages = 1
+	}
+	if page < 1 || page > totalPages {
+		page = defaultPage
+	}
+
+	start := (page - 1) * limit
+	end := start + limit
+	if end > len(artists) {
+		end = len(artists)
+	}
+
+	return PagedArtists{
+		Artists:    artists[start:end],
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages,
+		HasNext:    page < totalPages,
+		HasPrev:    page > 1,
+	}
+}
+
+// paginationParams reads the page and limit query parameters, invalid values fall back to the defaults
+func paginationParams(r *http.Request) (page, limit int) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil {
+		page = defaultPage
+	}
+	limit, err = strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultLimit
+	}
+	return page, limit
+}
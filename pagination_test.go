@@ -0,0 +1,110 @@
+package main
+
+import (
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestQueryParams(t *testing.T) {
+	values := url.Values{"country": {"france"}, "sort": {"name"}, "page": {"1"}}
+	got, err := url.ParseQuery(QueryParams(values, "page", "2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := url.Values{"country": {"france"}, "sort": {"name"}, "page": {"2"}}
+	if got.Encode() != want.Encode() {
+		t.Errorf("QueryParams = %s, want %s", got.Encode(), want.Encode())
+	}
+	if values.Get("page") != "1" {
+		t.Errorf("QueryParams modified its values, page = %s", values.Get("page"))
+	}
+	if got := QueryParams(nil, "page", "3"); got != "page=3" {
+		t.Errorf("QueryParams(nil) = %s, want page=3", got)
+	}
+}
+
+func TestPageLinks(t *testing.T) {
+	query := url.Values{"country": {"new zealand"}, "limit": {"1"}}
+	page := paginate(testArtists(), 2, 1).withQuery(query)
+	if got, want := nextPage(page), "?country=new+zealand&limit=1&page=3"; got != want {
+		t.Errorf("nextPage = %s, want %s", got, want)
+	}
+	if got, want := prevPage(page), "?country=new+zealand&limit=1&page=1"; got != want {
+		t.Errorf("prevPage = %s, want %s", got, want)
+	}
+}
+
+// pageLink returns the unescaped href of the pagination link labelled label in body, empty when there is none
+func pageLink(body, label string) string {
+	match := regexp.MustCompile(`href="([^"]*)" class="page-link">` + label).FindStringSubmatch(body)
+	if match == nil {
+		return ""
+	}
+	return html.UnescapeString(match[1])
+}
+
+func TestFiltersSurvivePageTransitions(t *testing.T) {
+	artists := append(testArtists(), Artists{
+		ID: 4, Name: "Air", Members: []string{"Nicolas Godin"}, CreationDate: 1995,
+		DatesLocations: Relations{ID: 4, DatesLocations: map[string][]string{"versailles-france": {"04-04-2020"}}},
+	})
+	handler := newTestServer(t, newTestApp(t, artists))
+	french := []string{"Pink Floyd", "Daft Punk", "Air"}
+
+	// shown returns the french artists listed on the page, Queen never played in France
+	shown := func(body string) []string {
+		if strings.Contains(body, "Queen") {
+			t.Error("the page lists Queen")
+		}
+		var names []string
+		for _, name := range french {
+			if strings.Contains(body, name) {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+
+	target := "/?country=france&limit=1"
+	seen := make(map[string]bool)
+	for page := 1; page <= len(french); page++ {
+		w := serve(handler, http.MethodGet, target, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d, want %d", target, w.Code, http.StatusOK)
+		}
+		names := shown(w.Body.String())
+		if len(names) != 1 || seen[names[0]] {
+			t.Fatalf("page %d lists %v, want a single french artist not listed before", page, names)
+		}
+		seen[names[0]] = true
+
+		next := pageLink(w.Body.String(), "Next")
+		if page == len(french) {
+			break
+		}
+		if next == "" {
+			t.Fatalf("page %d has no next link", page)
+		}
+		query, err := url.ParseQuery(strings.TrimPrefix(next, "?"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if query.Get("country") != "france" || query.Get("limit") != "1" || query.Get("page") != strconv.Itoa(page+1) {
+			t.Fatalf("the next link of page %d is %s, want the country and limit kept", page, next)
+		}
+		target = "/" + next
+	}
+
+	// and back again
+	w := serve(handler, http.MethodGet, target, nil)
+	prev := pageLink(w.Body.String(), "Previous")
+	query, _ := url.ParseQuery(strings.TrimPrefix(prev, "?"))
+	if query.Get("country") != "france" || query.Get("page") != strconv.Itoa(len(french)-1) {
+		t.Errorf("the previous link of the last page is %s, want the country kept", prev)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// artistFields maps the JSON names of the Artists fields to their index in the struct
+// fields without a json tag keep their Go name, like encoding/json does
+var artistFields = fieldIndex(reflect.TypeOf(Artists{}))
+
+// fieldIndex returns the JSON name to field index map of the struct type t
+func fieldIndex(t reflect.Type) map[string]int {
+	index := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		index[name] = i
+	}
+	return index
+}
+
+// parseFields splits the comma-separated fields query parameter, every name must be a JSON field of Artists
+func parseFields(value string) ([]string, error) {
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if _, found := artistFields[field]; !found {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// projectArtist returns only the given fields of a, keyed by their JSON name
+// the fields are expected to come from parseFields, unknown ones are skipped
+// datesLocations is the heaviest field so it's only ever included when it's requested explicitly
+func projectArtist(a Artists, fields []string) map[string]interface{} {
+	value := reflect.ValueOf(a)
+	projected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if i, found := artistFields[field]; found {
+			projected[field] = value.Field(i).Interface()
+		}
+	}
+	return projected
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// artistLoader fetches the artists from the API and merges them with their relations and the local data
+// the artists response is cached so the API is only hit once per TTL window, the relations are cached per artist
+type artistLoader struct {
+	cfg       Config
+	metrics   *Metrics
+	genres    map[int]string
+	fetcher   Fetcher
+	artists   *Cache[[]Artists]
+	relations *relationCache
+	// breaker guards every call to the API, the artists and the relations alike
+	breaker *CircuitBreaker
+}
+
+// prefetchedRelations is the number of artists whose relations are fetched by LoadFirst before the server starts
+// the relations of the others are fetched right after by a background Load, see loadRemainingRelations
+const prefetchedRelations = 20
+
+// newArtistLoader returns an artistLoader caching the API responses for cfg.CacheTTL
+// genres maps the artist ids to the genres merged into the artists, every API call goes through fetcher
+func newArtistLoader(cfg Config, metrics *Metrics, genres map[int]string, fetcher Fetcher) *artistLoader {
+	ttl := time.Duration(cfg.CacheTTL)
+	breaker := NewCircuitBreaker(apiBreakerThreshold, apiBreakerRecovery)
+	return &artistLoader{
+		cfg:       cfg,
+		metrics:   metrics,
+		genres:    genres,
+		fetcher:   fetcher,
+		artists:   &Cache[[]Artists]{TTL: ttl},
+		relations: &relationCache{ttl: ttl, metrics: metrics, fetcher: fetcher, breaker: breaker},
+		breaker:   breaker,
+	}
+}
+
+// Load fetches the artists and returns them with their concerts and genres
+// every relation is fetched so the filters and the stats see all the concerts
+// the returned slice is a new one every time so it can be handed to App.setArtists
+func (l *artistLoader) Load(ctx context.Context) ([]Artists, error) {
+	return l.load(ctx, -1)
+}
+
+// LoadFirst is Load only fetching the relations of the first prefetchedRelations artists, so the server starts quickly
+// the others only carry the relations fetched so far until the next Load
+func (l *artistLoader) LoadFirst(ctx context.Context) ([]Artists, error) {
+	return l.load(ctx, prefetchedRelations)
+}
+
+// load fetches the artists and the relations of the first prefetch of them, a negative prefetch means all of them
+func (l *artistLoader) load(ctx context.Context, prefetch int) ([]Artists, error) {
+	cached, err := l.artists.GetOrFetch(func() ([]Artists, error) {
+		var artists []Artists
+		err := fetchDataWithRetry(ctx, l.breaker, l.fetcher, l.cfg.APIBaseURL+"/artists", &artists, 5)
+		if err != nil {
+			l.metrics.fetchErrors.Inc()
+		}
+		return artists, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching artists: %w", err)
+	}
+
+	// duplicates are only reported, findArtist serves the first artist with a given id
+	if err := validateArtists(cached); err != nil {
+		slog.Warn("artist data has duplicates", slog.Any("error", err))
+	}
+
+	if prefetch < 0 {
+		prefetch = len(cached)
+	}
+	l.relations.Prefetch(ctx, cached, prefetch)
+
+	// Map relations to artists, on a copy since the cached slice may still be the one being served
+	artists := make([]Artists, 0, len(cached))
+	for _, artist := range cached {
+		if relation, found := l.relations.Cached(artist.ID); found {
+			artist.DatesLocations = relation
+		}
+		artist.FirstAlbumDate = parseFirstAlbum(artist.FirstAlbum)
+		artists = append(artists, artist)
+	}
+
+	// Merge the genres from the local genres file
+	for i := range artists {
+		artists[i].Genre = l.genres[artists[i].ID]
+	}
+	return artists, nil
+}
+
+// validateArtists returns an error listing every artist id used more than once
+func validateArtists(artists []Artists) error {
+	seen := make(map[int]int, len(artists))
+	var duplicates []string
+	for _, artist := range artists {
+		seen[artist.ID]++
+		if seen[artist.ID] == 2 {
+			duplicates = append(duplicates, strconv.Itoa(artist.ID))
+		}
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("duplicate artist ids: %s", strings.Join(duplicates, ", "))
+	}
+	return nil
+}
+
+// loadRemainingRelations loads the artists with all their relations in the background and swaps them in
+// it follows a LoadFirst at startup so the aggregates don't stay limited to the prefetched artists until the first refresh
+func loadRemainingRelations(ctx context.Context, app *App) {
+	go func() {
+		loadCtx, cancel := context.WithTimeout(ctx, time.Minute)
+		defer cancel()
+		artists, err := app.loadArtists(loadCtx)
+		if err != nil {
+			slog.Error("error loading the remaining relations", slog.Any("error", err))
+			return
+		}
+		app.setArtists(artists)
+		slog.Info("relations loaded", slog.Int("artists", len(artists)))
+	}()
+}
+
+// StartDataRefresh reloads the artist data every interval in the background until ctx is done
+// a failed refresh is logged and the current data is kept
+func StartDataRefresh(ctx context.Context, app *App, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			start := time.Now()
+			refreshCtx, cancel := context.WithTimeout(ctx, time.Minute)
+			artists, err := app.loadArtists(refreshCtx)
+			cancel()
+			if err != nil {
+				slog.Error("data refresh failed, keeping the current data", slog.Any("error", err))
+				continue
+			}
+			app.setArtists(artists)
+			slog.Info("data refreshed", slog.Int("artists", len(artists)), slog.Duration("duration", time.Since(start)))
+		}
+	}()
+}
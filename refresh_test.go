@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestValidateArtists(t *testing.T) {
+	if err := validateArtists(testArtists()); err != nil {
+		t.Errorf("validateArtists of unique ids = %v, want nil", err)
+	}
+	if err := validateArtists(nil); err != nil {
+		t.Errorf("validateArtists(nil) = %v, want nil", err)
+	}
+
+	// every duplicated id is listed once, in the order it was first duplicated
+	artists := []Artists{
+		{ID: 1, Name: "Queen"},
+		{ID: 2, Name: "Pink Floyd"},
+		{ID: 1, Name: "Queen again"},
+		{ID: 3, Name: "Daft Punk"},
+		{ID: 3, Name: "Daft Punk again"},
+		{ID: 1, Name: "Queen a third time"},
+	}
+	err := validateArtists(artists)
+	if err == nil {
+		t.Fatal("validateArtists of duplicate ids = nil, want an error")
+	}
+	if want := "duplicate artist ids: 1, 3"; err.Error() != want {
+		t.Errorf("validateArtists = %q, want %q", err, want)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// weights of the RelatedArtists heuristics
+const (
+	sharedMemberWeight   = 3
+	sharedLocationWeight = 1
+)
+
+// relatedLimit is the number of artists returned by /api/artists/{id}/related
+const relatedLimit = 5
+
+// ScoredArtist is an artist along with how related it is to another one
+type ScoredArtist struct {
+	Artists
+	Score int `json:"score"`
+}
+
+// RelatedArtists returns up to limit of others related to target, the most related first
+// each member name they share scores 3 and each concert location they share scores 1
+// ties are broken by creation date, oldest first, artists scoring 0 and target itself are left out
+func RelatedArtists(target Artists, others []Artists, limit int) []ScoredArtist {
+	members := make(map[string]bool, len(target.Members))
+	for _, member := range target.Members {
+		members[strings.ToLower(strings.TrimSpace(member))] = true
+	}
+
+	related := []ScoredArtist{}
+	for _, other := range others {
+		if other.ID == target.ID {
+			continue
+		}
+		score := 0
+		for _, member := range other.Members {
+			if members[strings.ToLower(strings.TrimSpace(member))] {
+				score += sharedMemberWeight
+			}
+		}
+		for location := range other.DatesLocations.DatesLocations {
+			if _, found := target.DatesLocations.DatesLocations[location]; found {
+				score += sharedLocationWeight
+			}
+		}
+		if score > 0 {
+			related = append(related, ScoredArtist{Artists: other, Score: score})
+		}
+	}
+
+	sort.Slice(related, func(i, j int) bool {
+		if related[i].Score != related[j].Score {
+			return related[i].Score > related[j].Score
+		}
+		if related[i].CreationDate != related[j].CreationDate {
+			return related[i].CreationDate < related[j].CreationDate
+		}
+		return related[i].ID < related[j].ID
+	})
+	if limit >= 0 && len(related) > limit {
+		related = related[:limit]
+	}
+	return related
+}
+
+// apiArtistRelated responds with the artists most related to artist as JSON
+// the other artists are compared on the concerts fetched so far
+func (a *App) apiArtistRelated(w http.ResponseWriter, r *http.Request, artist Artists) {
+	artist = a.withRelations(r.Context(), artist)
+	related := RelatedArtists(artist, a.artists(), relatedLimit)
+	writeEnvelope(w, r, http.StatusOK, related, APIMeta{Total: len(related)})
+}
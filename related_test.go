@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// relatedFixture are artists sharing members and concert locations in known amounts
+func relatedFixture() []Artists {
+	locations := func(keys ...string) Relations {
+		relations := Relations{DatesLocations: make(map[string][]string)}
+		for _, key := range keys {
+			relations.DatesLocations[key] = []string{"01-01-2020"}
+		}
+		return relations
+	}
+	return []Artists{
+		{ID: 1, Name: "Cream", CreationDate: 1966, Members: []string{"Eric Clapton", "Ginger Baker", "Jack Bruce"},
+			DatesLocations: locations("london-uk", "paris-france", "new_york-usa")},
+		// 2 shared members and 1 shared location
+		{ID: 2, Name: "Blind Faith", CreationDate: 1969, Members: []string{"eric clapton", "Ginger Baker", "Steve Winwood"},
+			DatesLocations: locations("london-uk", "tokyo-japan")},
+		// 1 shared member
+		{ID: 3, Name: "Derek and the Dominos", CreationDate: 1970, Members: []string{"Eric Clapton"}},
+		// 3 shared locations, ties with Derek and the Dominos but is older
+		{ID: 4, Name: "The Who", CreationDate: 1964, Members: []string{"Roger Daltrey"},
+			DatesLocations: locations("london-uk", "paris-france", "new_york-usa")},
+		// 1 shared location
+		{ID: 5, Name: "Queen", CreationDate: 1970, Members: []string{"Freddie Mercury"},
+			DatesLocations: locations("paris-france")},
+		// nothing shared
+		{ID: 6, Name: "Daft Punk", CreationDate: 1993, Members: []string{"Thomas Bangalter"},
+			DatesLocations: locations("tokyo-japan")},
+		// 1 shared location, ties with Queen and is older
+		{ID: 7, Name: "Pink Floyd", CreationDate: 1965, Members: []string{"Roger Waters"},
+			DatesLocations: locations("new_york-usa")},
+	}
+}
+
+// relatedIDs returns the ids and scores of related, in order
+func relatedIDs(related []ScoredArtist) [][2]int {
+	ids := [][2]int{}
+	for _, artist := range related {
+		ids = append(ids, [2]int{artist.ID, artist.Score})
+	}
+	return ids
+}
+
+func TestRelatedArtists(t *testing.T) {
+	artists := relatedFixture()
+	tests := []struct {
+		name  string
+		limit int
+		want  [][2]int
+	}{
+		{"all", -1, [][2]int{{2, 7}, {4, 3}, {3, 3}, {7, 1}, {5, 1}}},
+		{"limited", 2, [][2]int{{2, 7}, {4, 3}}},
+		{"none", 0, [][2]int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := relatedIDs(RelatedArtists(artists[0], artists, tt.limit))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RelatedArtists = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// an artist sharing nothing has no related artists, and is never related to itself
+	if got := RelatedArtists(artists[5], artists[5:6], relatedLimit); len(got) != 0 {
+		t.Errorf("RelatedArtists = %v, want none", relatedIDs(got))
+	}
+}
+
+func TestAPIArtistRelated(t *testing.T) {
+	handler := newTestServer(t, newTestApp(t, relatedFixture()))
+	w := serve(handler, http.MethodGet, "/api/artists/1/related", jsonHeader)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	envelope := decodeEnvelope[[]ScoredArtist](t, w)
+	if got, want := relatedIDs(envelope.Data), [][2]int{{2, 7}, {4, 3}, {3, 3}, {7, 1}, {5, 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("related = %v, want %v", got, want)
+	}
+	if envelope.Data[0].Name != "Blind Faith" {
+		t.Errorf("the most related artist is %q, want Blind Faith", envelope.Data[0].Name)
+	}
+
+	w = serve(handler, http.MethodGet, "/api/artists/42/related", jsonHeader)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("related of a missing artist = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
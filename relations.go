@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// relationFetchTimeout bounds the fetch of the relations of a single artist
+const relationFetchTimeout = 5 * time.Second
+
+// relationCache lazily fetches the relations of every artist from its RelationsURL and caches them for ttl
+type relationCache struct {
+	ttl     time.Duration
+	metrics *Metrics
+	fetcher Fetcher
+	breaker *CircuitBreaker
+
+	// entries maps an artist id to its *Cache[Relations]
+	entries sync.Map
+}
+
+// entry returns the cache of the artist with the given id, creating it on first use
+func (c *relationCache) entry(id int) *Cache[Relations] {
+	entry, _ := c.entries.LoadOrStore(id, &Cache[Relations]{TTL: c.ttl})
+	return entry.(*Cache[Relations])
+}
+
+// Cached returns the relations of the artist fetched so far without calling the API
+func (c *relationCache) Cached(id int) (Relations, bool) {
+	entry, ok := c.entries.Load(id)
+	if !ok {
+		return Relations{}, false
+	}
+	return entry.(*Cache[Relations]).Cached()
+}
+
+// Get returns the relations of artist, fetched from its RelationsURL when they aren't cached or are older than the TTL
+// the fetch gets relationFetchTimeout, artists without a RelationsURL like the local ones keep their own DatesLocations
+// it's detached from the cancellation of ctx so a client going away neither counts as an API failure for the breaker
+// nor fails the other requests waiting on the same fetch
+func (c *relationCache) Get(ctx context.Context, artist Artists) (Relations, error) {
+	if artist.RelationsURL == "" {
+		return artist.DatesLocations, nil
+	}
+	return c.entry(artist.ID).GetOrFetch(func() (Relations, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), relationFetchTimeout)
+		defer cancel()
+
+		var relations Relations
+		err := c.breaker.Do(func() error {
+			return c.fetcher.Fetch(ctx, artist.RelationsURL, &relations)
+		})
+		if err != nil && c.metrics != nil {
+			c.metrics.fetchErrors.Inc()
+		}
+		// the relations are keyed by the artist id so a mismatch means the API data is inconsistent
+		if err == nil && relations.ID != artist.ID {
+			slog.WarnContext(ctx, "relations id doesn't match its artist", slog.Int("artist", artist.ID), slog.Int("relations", relations.ID))
+		}
+		return relations, err
+	})
+}
+
+// Prefetch warms up the cache with the relations of the first n artists, a few at a time
+// failures are only logged, those artists are fetched again when their page is requested
+func (c *relationCache) Prefetch(ctx context.Context, artists []Artists, n int) {
+	if n > len(artists) {
+		n = len(artists)
+	}
+	var group errgroup.Group
+	group.SetLimit(5)
+	for _, artist := range artists[:n] {
+		group.Go(func() error {
+			if _, err := c.Get(ctx, artist); err != nil {
+				slog.Warn("error prefetching relations", slog.Int("artist", artist.ID), slog.Any("error", err))
+			}
+			return nil
+		})
+	}
+	group.Wait()
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Middleware wraps a handler, like RateLimit, RequireJSON or CORS do
+type Middleware func(http.Handler) http.Handler
+
+// chain wraps handler in middlewares, the first one is the outermost so it sees the request first
+func chain(handler http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// anyMethod is the key of the routes registered with Handle, they match every method
+const anyMethod = ""
+
+// routeMethods are the handlers of a pattern keyed by method, each already wrapped in its middlewares
+type routeMethods struct {
+	pattern  string
+	handlers map[string]http.Handler
+	// first is the method of the first route of the pattern, OPTIONS requests go to it, see Router.dispatch
+	first string
+}
+
+// Router dispatches requests on their path and method, every route carrying its own middlewares
+// paths are matched like http.ServeMux does, a pattern ending in a slash matches everything below it
+type Router struct {
+	mux    *http.ServeMux
+	routes map[string]*routeMethods
+	// NotAllowed answers the requests whose path has routes but none for their method, nil means a plain text 405
+	NotAllowed http.Handler
+}
+
+// NewRouter returns a Router without routes
+func NewRouter() *Router {
+	return &Router{mux: http.NewServeMux(), routes: make(map[string]*routeMethods)}
+}
+
+// Get registers handler for the GET and HEAD requests of pattern, wrapped in middlewares
+func (rt *Router) Get(pattern string, handler http.HandlerFunc, middlewares ...Middleware) {
+	rt.add(http.MethodGet, pattern, handler, middlewares)
+}
+
+// Post registers handler for the POST requests of pattern, wrapped in middlewares
+func (rt *Router) Post(pattern string, handler http.HandlerFunc, middlewares ...Middleware) {
+	rt.add(http.MethodPost, pattern, handler, middlewares)
+}
+
+// Delete registers handler for the DELETE requests of pattern, wrapped in middlewares
+func (rt *Router) Delete(pattern string, handler http.HandlerFunc, middlewares ...Middleware) {
+	rt.add(http.MethodDelete, pattern, handler, middlewares)
+}
+
+// Handle registers handler for every method of pattern, wrapped in middlewares
+// it's for the handlers doing their own method checks, like the file server or a mounted Router
+func (rt *Router) Handle(pattern string, handler http.Handler, middlewares ...Middleware) {
+	rt.add(anyMethod, pattern, handler, middlewares)
+}
+
+// add registers the handler of method for pattern, the pattern is only given to the ServeMux the first time
+// registering the same method and pattern twice panics like http.ServeMux does
+func (rt *Router) add(method, pattern string, handler http.Handler, middlewares []Middleware) {
+	methods, found := rt.routes[pattern]
+	if !found {
+		methods = &routeMethods{pattern: pattern, handlers: make(map[string]http.Handler), first: method}
+		rt.routes[pattern] = methods
+		rt.mux.Handle(pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rt.dispatch(w, r, methods)
+		}))
+	}
+	if _, taken := methods.handlers[method]; taken {
+		panic("router: multiple registrations for " + strings.TrimSpace(method+" "+pattern))
+	}
+	methods.handlers[method] = chain(handler, middlewares...)
+}
+
+// ServeHTTP dispatches the request to the route matching its path and method
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.mux.ServeHTTP(w, r)
+}
+
+// dispatch serves the request with the handler of its method among methods and records its pattern for the metrics
+// HEAD falls back to GET like net/http does, and OPTIONS to the first route of the pattern
+// so the CORS middleware of that route can answer preflight requests
+func (rt *Router) dispatch(w http.ResponseWriter, r *http.Request, methods *routeMethods) {
+	// a mounted Router matching after this one overwrites it with its more specific pattern
+	if pattern, ok := r.Context().Value(routePatternKey).(*string); ok {
+		*pattern = methods.pattern
+	}
+	handler, found := methods.handlers[r.Method]
+	if !found && r.Method == http.MethodHead {
+		handler, found = methods.handlers[http.MethodGet]
+		// the GET handlers only accept GET, the server still drops the body it writes for the HEAD request
+		if found {
+			get := *r
+			get.Method = http.MethodGet
+			r = &get
+		}
+	}
+	if !found && r.Method == http.MethodOptions {
+		handler, found = methods.handlers[methods.first]
+	}
+	if !found {
+		handler, found = methods.handlers[anyMethod]
+	}
+	if found {
+		handler.ServeHTTP(w, r)
+		return
+	}
+
+	allowed := make([]string, 0, len(methods.handlers)+1)
+	for method := range methods.handlers {
+		allowed = append(allowed, method)
+		if method == http.MethodGet {
+			allowed = append(allowed, http.MethodHead)
+		}
+	}
+	sort.Strings(allowed)
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	if rt.NotAllowed != nil {
+		rt.NotAllowed.ServeHTTP(w, r)
+		return
+	}
+	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+}
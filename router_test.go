@@ -0,0 +1,153 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRouterHead(t *testing.T) {
+	router := NewRouter()
+	router.Get("/page", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Write([]byte("page"))
+	})
+
+	w := serve(router, http.MethodHead, "/page", nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("HEAD /page = %d, want %d", w.Code, http.StatusOK)
+	}
+	w = serve(router, http.MethodPost, "/page", nil)
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, HEAD" {
+		t.Errorf("POST /page = %d with Allow %q, want %d with GET, HEAD", w.Code, w.Header().Get("Allow"), http.StatusMethodNotAllowed)
+	}
+}
+
+// recordingMiddleware appends name to order when the request goes in and name+" done" when it comes out
+func recordingMiddleware(order *[]string, name string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*order = append(*order, name)
+			next.ServeHTTP(w, r)
+			*order = append(*order, name+" done")
+		})
+	}
+}
+
+func TestChainOrder(t *testing.T) {
+	var order []string
+	handler := chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	}), recordingMiddleware(&order, "first"), recordingMiddleware(&order, "second"), recordingMiddleware(&order, "third"))
+
+	serve(handler, http.MethodGet, "/", nil)
+	want := "first,second,third,handler,third done,second done,first done"
+	if got := strings.Join(order, ","); got != want {
+		t.Errorf("order = %s, want %s", got, want)
+	}
+}
+
+func TestRouterMiddlewareOrder(t *testing.T) {
+	var order []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	}
+	// the middlewares of a mounted router run after the ones it's mounted with, each route only gets its own
+	inner := NewRouter()
+	inner.Get("/api/a", handler, recordingMiddleware(&order, "a1"), recordingMiddleware(&order, "a2"))
+	inner.Post("/api/a", handler, recordingMiddleware(&order, "post"))
+	inner.Get("/api/b", handler)
+	outer := NewRouter()
+	outer.Handle("/api/", inner, recordingMiddleware(&order, "api"))
+	outer.Get("/page", handler, recordingMiddleware(&order, "page"))
+
+	tests := []struct {
+		method, path, want string
+	}{
+		{http.MethodGet, "/api/a", "api,a1,a2,handler,a2 done,a1 done,api done"},
+		{http.MethodPost, "/api/a", "api,post,handler,post done,api done"},
+		{http.MethodGet, "/api/b", "api,handler,api done"},
+		{http.MethodGet, "/page", "page,handler,page done"},
+	}
+	for _, tt := range tests {
+		order = nil
+		serve(outer, tt.method, tt.path, nil)
+		if got := strings.Join(order, ","); got != tt.want {
+			t.Errorf("%s %s: order = %s, want %s", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRouterNotAllowed(t *testing.T) {
+	router := NewRouter()
+	router.Get("/page", func(w http.ResponseWriter, r *http.Request) {})
+	router.Delete("/page", func(w http.ResponseWriter, r *http.Request) {})
+
+	w := serve(router, http.MethodPost, "/page", nil)
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "DELETE, GET, HEAD" {
+		t.Errorf("plain 405: got %d with Allow %q", w.Code, w.Header().Get("Allow"))
+	}
+
+	router.NotAllowed = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	if w := serve(router, http.MethodPost, "/page", nil); w.Code != http.StatusTeapot {
+		t.Errorf("NotAllowed: got %d, want %d", w.Code, http.StatusTeapot)
+	}
+	if w := serve(router, http.MethodGet, "/other", nil); w.Code != http.StatusNotFound {
+		t.Errorf("unknown path: got %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestRouterDuplicateRoute(t *testing.T) {
+	router := NewRouter()
+	router.Get("/page", func(w http.ResponseWriter, r *http.Request) {})
+	defer func() {
+		if recover() == nil {
+			t.Error("registering GET /page twice didn't panic")
+		}
+	}()
+	router.Get("/page", func(w http.ResponseWriter, r *http.Request) {})
+}
+
+func TestBuildRouterMiddlewareOrder(t *testing.T) {
+	handler := newTestServer(t, newTestApp(t, testArtists()))
+
+	// the global middlewares wrap the 404s and 405s of the routers too
+	for _, target := range []string{"/", "/no-such-page", "/api/artists"} {
+		w := serve(handler, http.MethodPost, target, jsonHeader)
+		if w.Header().Get("X-Request-ID") == "" || w.Header().Get("X-Content-Type-Options") != "nosniff" {
+			t.Errorf("POST %s: missing the headers of RequestID and SecureHeaders", target)
+		}
+	}
+
+	// the restricted paths are checked before the file server can list them
+	if w := serve(handler, http.MethodGet, "/static/", nil); w.Code != http.StatusForbidden {
+		t.Errorf("GET /static/ = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	// RequireJSON runs before the handler so a POST without a JSON body never reaches it
+	r := httptest.NewRequest(http.MethodPost, "/api/artists/batch", strings.NewReader("ids=1"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("form POST /api/artists/batch = %d, want %d", w.Code, http.StatusUnsupportedMediaType)
+	}
+
+	// once the global rate limit is used up the pages are refused but the health probes aren't behind it
+	limited := false
+	for i := 0; i < defaultRateBurst+1 && !limited; i++ {
+		limited = serve(handler, http.MethodGet, "/about", nil).Code == http.StatusTooManyRequests
+	}
+	if !limited {
+		t.Fatalf("GET /about was never rate limited")
+	}
+	if w := serve(handler, http.MethodGet, "/health", nil); w.Code != http.StatusOK {
+		t.Errorf("GET /health once rate limited = %d, want %d", w.Code, http.StatusOK)
+	}
+}
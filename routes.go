@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// buildRouter wires every route and the middleware chain around them
+// it's separate from main so the whole server can be built from an App without listening on a port
+// the background work of the middlewares, like pruning the rate limiters, stops once ctx is done
+func buildRouter(ctx context.Context, app *App, logger *slog.Logger) (http.Handler, error) {
+	errorTmpl := app.template("error")
+	notAllowed := methodNotAllowed(errorTmpl)
+
+	// Define route handlers
+	// the index is registered for every method since it also answers the unknown paths with a 404
+	router := NewRouter()
+	router.NotAllowed = notAllowed
+	// the HTML pages say explicitly they can be indexed, the API and the exports say they can't, see NoIndex
+	indexable := robotsTag("index, follow")
+	router.Handle("/", http.HandlerFunc(app.IndexHandler), indexable)
+	router.Get("/about", app.AboutHandler, indexable)
+	router.Get("/readme", app.ReadmeHandler, indexable)
+	searchBody := MaxBodySize(maxSearchBodySize)
+	router.Get("/search", app.SearchHandler, indexable, searchBody)
+	router.Get("/advanced-search", app.AdvancedSearchHandler, indexable, searchBody)
+	router.Get("/artist/", app.ArtistHandler, indexable)
+	router.Get("/compare", app.CompareHandler, indexable)
+	router.Get("/random", app.RandomHandler, indexable)
+	router.Get("/sitemap.xml", app.SitemapHandler)
+	router.Get("/robots.txt", app.RobotsHandler)
+	router.Get("/feed.rss", app.FeedHandler)
+	router.Get("/proxy/image", app.ImageProxyHandler)
+	router.Get("/ws/refresh", app.WSRefreshHandler)
+	router.Get("/events/artists", app.EventsHandler)
+
+	// JSON API routes, grouped under /api/ so they all go through RequireJSON
+	// and wrapped in CORS so third-party frontends can call them
+	cors := CORS(app.Config.AllowedOrigins)
+	adminAuth := BasicAuth(app.Config.AdminUser, app.Config.AdminPassword)
+	api := NewRouter()
+	api.NotAllowed = notAllowed
+	api.Get("/api/artists", app.APIArtistsHandler, cors)
+	api.Get("/api/artists/", app.APIArtistHandler, cors)
+	api.Post("/api/artists/batch", app.APIArtistsBatchHandler, cors)
+	api.Get("/api/stats/top-locations", app.APITopLocationsHandler, cors)
+	api.Get("/api/stats/bands-by-decade", app.APIBandsByDecadeHandler, cors)
+	api.Get("/api/stats/shared-members", app.APISharedMembersHandler, cors)
+	api.Get("/api/stats/member-count-distribution", app.APIMemberCountDistributionHandler, cors)
+	api.Get("/api/stats/longest-career", app.APILongestCareerHandler, cors)
+	api.Get("/api/locations", app.APILocationsHandler, cors)
+	// the circuit breaker state is an admin endpoint even though it's under /api/
+	api.Get("/api/circuit-status", app.CircuitStatusHandler, adminAuth)
+	api.Get("/api/version", app.VersionHandler)
+	// autocomplete is called on every keystroke so on top of the global limit each IP gets 5 requests per second, with a burst of 10 for fast typing
+	// it's not the 50 per second first planned for it, it sits behind the global limit (10 per second by default)
+	// so a 50 per second limiter would never be the one refusing a request
+	autocompleteLimit := RateLimit(ctx, 5, 10, errorTmpl)
+	api.Get("/api/autocomplete", app.APIAutocompleteHandler, autocompleteLimit, cors)
+	api.Get("/api/autocomplete/locations", app.APILocationAutocompleteHandler, autocompleteLimit, cors)
+	api.Handle("/api/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSONError(w, r, http.StatusNotFound, "Not found")
+	}))
+	jsonBody := MaxBodySize(maxJSONBodySize)
+	router.Handle("/api/", api, NoIndex, jsonBody, RequireJSON)
+
+	// Exports are heavier than a page so on top of the global limit each IP gets 5 of them per minute
+	exportLimit := RateLimit(ctx, 5.0/60, 5, errorTmpl)
+	router.Get("/export/artists.csv", app.ExportCSVHandler, NoIndex, exportLimit)
+
+	// Admin routes, behind HTTP Basic Auth
+	router.Post("/admin/artists", app.AdminAddArtistHandler, adminAuth, jsonBody)
+	router.Delete("/admin/artists/", app.AdminDeleteArtistHandler, adminAuth, jsonBody)
+
+	// Serve static files
+	files, err := customFileServer(app.Config, errorTmpl)
+	if err != nil {
+		return nil, err
+	}
+	router.Get("/static/", http.StripPrefix("/static/", files).ServeHTTP)
+	router.Get("/assets/", files.ServeHTTP)
+
+	// Health probes, metrics, the favicon and the manifest are served by the root router so they bypass rate limiting and the restricted path checks
+	root := NewRouter()
+	root.NotAllowed = notAllowed
+	root.Get("/health", app.HealthHandler)
+	root.Get("/ready", app.ReadyHandler)
+	root.Get("/metrics", app.Metrics.Handler().ServeHTTP)
+	root.Get("/favicon.ico", FaviconHandler(app.Config, errorTmpl))
+	root.Get("/manifest.json", app.ManifestHandler)
+
+	requestLogger := RequestLogger(logger, app.SLOs, "/health", "/ready")
+	rateLimit := RateLimit(ctx, app.Config.RateLimit, app.Config.RateBurst, errorTmpl)
+	secureHeaders := SecureHeaders(app.Config.CSP, app.Config.Dev)
+	restrict := NewRestrictMiddleware(app.Config.RestrictedPaths, errorTmpl)
+	root.Handle("/", router, rateLimit, restrict)
+
+	return chain(root, Recovery(errorTmpl), RequestID, requestLogger, app.Metrics.Middleware, secureHeaders), nil
+}
+
+// methodNotAllowed answers the requests the routers have no route for with a 405
+// as JSON under /api/ and /admin/ and with the error template elsewhere
+func methodNotAllowed(errorTmpl *template.Template) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/") || strings.HasPrefix(r.URL.Path, "/admin/") {
+			writeJSONError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		WriteError(r.Context(), w, MethodNotAllowedError(), errorTmpl)
+	})
+}
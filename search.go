@@ -0,0 +1,141 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// defaultFuzzyThreshold is the similarity an artist needs to be returned by the fuzzy search of /search
+const defaultFuzzyThreshold = 0.3
+
+// trigrams returns the set of consecutive 3 character sequences of s, lowercased
+// a string shorter than 3 characters is its own single trigram
+func trigrams(s string) map[string]bool {
+	runes := []rune(strings.ToLower(strings.TrimSpace(s)))
+	grams := make(map[string]bool)
+	if len(runes) < 3 {
+		if len(runes) > 0 {
+			grams[string(runes)] = true
+		}
+		return grams
+	}
+	for i := 0; i+3 <= len(runes); i++ {
+		grams[string(runes[i:i+3])] = true
+	}
+	return grams
+}
+
+// trigramSimilarity returns the Jaccard similarity of the trigram sets of a and b, from 0 (nothing shared) to 1 (same set)
+func trigramSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for gram := range a {
+		if b[gram] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// FuzzySearch returns the artists whose name or one of its members is more similar to query than threshold
+// similarity is the Jaccard similarity of the trigrams so typos still match, the most similar artists come first
+func FuzzySearch(query string, artists []Artists, threshold float64) []Artists {
+	queryGrams := trigrams(query)
+
+	type scored struct {
+		artist Artists
+		score  float64
+	}
+	var matches []scored
+	for _, artist := range artists {
+		best := trigramSimilarity(queryGrams, trigrams(artist.Name))
+		for _, member := range artist.Members {
+			if score := trigramSimilarity(queryGrams, trigrams(member)); score > best {
+				best = score
+			}
+		}
+		if best > threshold {
+			matches = append(matches, scored{artist, best})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	results := make([]Artists, len(matches))
+	for i, match := range matches {
+		results[i] = match.artist
+	}
+	return results
+}
+
+// Autocomplete returns up to limit artist and member names starting with query, case insensitive and sorted
+// a name shared by several artists is only suggested once
+func Autocomplete(query string, artists []Artists, limit int) []string {
+	query = strings.ToLower(strings.TrimSpace(query))
+	seen := make(map[string]bool)
+	suggestions := []string{}
+	add := func(name string) {
+		key := strings.ToLower(name)
+		if strings.HasPrefix(key, query) && !seen[key] {
+			seen[key] = true
+			suggestions = append(suggestions, name)
+		}
+	}
+	for _, artist := range artists {
+		add(artist.Name)
+		for _, member := range artist.Members {
+			add(member)
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		return strings.ToLower(suggestions[i]) < strings.ToLower(suggestions[j])
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// LocationAutocomplete returns up to limit concert locations, formatted with FormatLocation, starting with query
+// case insensitive and sorted, see locationNames and completeLocation
+func LocationAutocomplete(artists []Artists, query string, limit int) []string {
+	return completeLocation(locationNames(artists), query, limit)
+}
+
+// locationNames returns the unique concert locations of artists formatted with FormatLocation and sorted case insensitively
+func locationNames(artists []Artists) []string {
+	seen := make(map[string]bool)
+	names := []string{}
+	for _, artist := range artists {
+		for location := range artist.DatesLocations.DatesLocations {
+			name := FormatLocation(location)
+			if key := strings.ToLower(name); name != "" && !seen[key] {
+				seen[key] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+	return names
+}
+
+// completeLocation returns up to limit of the sorted names starting with query, case insensitive
+func completeLocation(names []string, query string, limit int) []string {
+	query = strings.ToLower(strings.TrimSpace(query))
+	suggestions := []string{}
+	for _, name := range names {
+		if len(suggestions) == limit {
+			break
+		}
+		if strings.HasPrefix(strings.ToLower(name), query) {
+			suggestions = append(suggestions, name)
+		}
+	}
+	return suggestions
+}
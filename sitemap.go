@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Sitemap is the root urlset element of a sitemap.xml
+type Sitemap struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []SitemapURL `xml:"url"`
+}
+
+// SitemapURL is a single page listed in the sitemap
+type SitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// buildSitemap lists the static pages and one page per artist, all last modified when the artist data was loaded
+func (a *App) buildSitemap() ([]byte, error) {
+	base := strings.TrimSuffix(a.Config.withDefaults().PublicURL, "/")
+	a.artistsMu.RLock()
+	lastMod := a.dataLastModified.UTC().Format("2006-01-02")
+	a.artistsMu.RUnlock()
+
+	sitemap := Sitemap{XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	for _, page := range []string{"/", "/about", "/readme"} {
+		sitemap.URLs = append(sitemap.URLs, SitemapURL{Loc: base + page, LastMod: lastMod})
+	}
+	for _, artist := range a.artists() {
+		sitemap.URLs = append(sitemap.URLs, SitemapURL{Loc: base + "/artist/" + strconv.Itoa(artist.ID), LastMod: lastMod})
+	}
+
+	body, err := xml.MarshalIndent(sitemap, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// SitemapHandler serves the sitemap.xml, it's built on the first request and cached until the artists are refreshed
+func (a *App) SitemapHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	a.sitemapMu.Lock()
+	if a.sitemap == nil {
+		sitemap, err := a.buildSitemap()
+		if err != nil {
+			a.sitemapMu.Unlock()
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		a.sitemap = sitemap
+	}
+	sitemap := a.sitemap
+	a.sitemapMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write(sitemap)
+}
+
+// RobotsHandler serves the robots.txt, disallowing the Config.RobotsDisallow paths and pointing crawlers to the sitemap
+func (a *App) RobotsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cfg := a.Config.withDefaults()
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	for _, path := range cfg.RobotsDisallow {
+		b.WriteString("Disallow: " + path + "\n")
+	}
+	b.WriteString("\nSitemap: " + strings.TrimSuffix(cfg.PublicURL, "/") + "/sitemap.xml\n")
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultSLO is the latency allowed for the routes without an SLO of their own
+const defaultSLO = 500 * time.Millisecond
+
+// SLOConfig is the maximum acceptable latency of the routes starting with Route
+type SLOConfig struct {
+	Route      string   `json:"route"`
+	MaxLatency Duration `json:"maxLatency"`
+}
+
+// loadSLOs reads the JSON file at path listing the route SLOs
+// a missing file isn't an error, every route then gets defaultSLO
+func loadSLOs(path string) ([]SLOConfig, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading SLO file: %w", err)
+	}
+	var slos []SLOConfig
+	if err := json.Unmarshal(data, &slos); err != nil {
+		return nil, fmt.Errorf("error parsing SLO file %s: %w", path, err)
+	}
+	return slos, nil
+}
+
+// matchRouteSLO returns the latency allowed for path, from the SLO with the longest route prefixing it
+// paths matching no SLO get defaultSLO
+func matchRouteSLO(path string, slos []SLOConfig) time.Duration {
+	best := -1
+	latency := defaultSLO
+	for _, slo := range slos {
+		if len(slo.Route) > best && strings.HasPrefix(path, slo.Route) && slo.MaxLatency > 0 {
+			best = len(slo.Route)
+			latency = time.Duration(slo.MaxLatency)
+		}
+	}
+	return latency
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"embed"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+)
+
+// embeddedFS holds the templates and every static asset, embedded at compile time so the binary is self-contained
+// Config.TemplateDir and Config.StaticDir are directories inside of it
+// the "_" partials are listed on their own since go:embed leaves them out of a directory
+//
+//go:embed templates templates/_*.html
+var embeddedFS embed.FS
+
+// staticFileSystem is an http.FileSystem that refuses to open directories
+// so a request for a directory gets a 403 instead of a listing of its files
+type staticFileSystem struct {
+	fs http.FileSystem
+}
+
+// Open opens the named file, directories are rejected with fs.ErrPermission
+func (s staticFileSystem) Open(name string) (http.File, error) {
+	file, err := s.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, fs.ErrPermission
+	}
+	return file, nil
+}
+
+// newStaticFileSystem returns the staticFileSystem serving the dir directory of the embedded files
+func newStaticFileSystem(dir string) (staticFileSystem, error) {
+	sub, err := fs.Sub(embeddedFS, dir)
+	if err != nil {
+		return staticFileSystem{}, err
+	}
+	return staticFileSystem{fs: http.FS(sub)}, nil
+}
+
+// faviconFile is the path of the favicon inside Config.StaticDir
+const faviconFile = "assets/favicon.ico"
+
+// FaviconHandler serves the embedded favicon so browsers asking for /favicon.ico don't fill the logs with 404s
+// the file is read once, if it isn't part of the build the usual 404 page is served instead
+func FaviconHandler(cfg Config, errorTmpl *template.Template) http.HandlerFunc {
+	icon, err := fs.ReadFile(embeddedFS, path.Join(cfg.withDefaults().StaticDir, faviconFile))
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			WriteError(r.Context(), w, NotFoundError("Page not found"), errorTmpl)
+			return
+		}
+		w.Header().Set("Content-Type", "image/x-icon")
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		w.Write(icon)
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LocationStat is the number of concerts played at one location across all the artists
+type LocationStat struct {
+	Location    string `json:"location"`
+	DisplayName string `json:"displayName"`
+	Count       int    `json:"count"`
+}
+
+// TopLocations returns the n locations with the most concerts across all the artists, most concerts first
+// locations with the same count are sorted by name so the order is stable
+func TopLocations(artists []Artists, n int) []LocationStat {
+	counts := make(map[string]int)
+	for _, artist := range artists {
+		for location, dates := range artist.DatesLocations.DatesLocations {
+			counts[location] += len(dates)
+		}
+	}
+
+	stats := make([]LocationStat, 0, len(counts))
+	for location, count := range counts {
+		stats = append(stats, LocationStat{
+			Location:    location,
+			DisplayName: FormatLocation(location),
+			Count:       count,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return stats[i].Location < stats[j].Location
+	})
+
+	if n < len(stats) {
+		stats = stats[:n]
+	}
+	return stats
+}
+
+// APITopLocationsHandler responds with the most toured locations as JSON
+// the n query parameter sets how many are returned, between 1 and 50, 10 by default
+func (a *App) APITopLocationsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	n, err := intParam(r.URL.Query(), "n", 10)
+	if err == nil && (n < 1 || n > 50) {
+		err = fmt.Errorf("n must be between 1 and 50")
+	}
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	top := TopLocations(a.artists(), n)
+	writeEnvelope(w, r, http.StatusOK, top, APIMeta{Total: len(top)})
+}
+
+// BandsByDecade counts how many artists were created in each decade, keyed like "1980s"
+// decades without any artist are left out
+func BandsByDecade(artists []Artists) map[string]int {
+	counts := make(map[string]int)
+	for _, artist := range artists {
+		counts[decadeOf(artist.CreationDate)]++
+	}
+	return counts
+}
+
+// DecadeStats is one bar of the bands by decade histogram
+type DecadeStats struct {
+	Decade string `json:"decade"`
+	Count  int    `json:"count"`
+}
+
+// APIBandsByDecadeHandler responds with the number of artists created per decade as JSON, oldest decade first
+func (a *App) APIBandsByDecadeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	counts := BandsByDecade(a.artists())
+	stats := make([]DecadeStats, 0, len(counts))
+	for decade, count := range counts {
+		stats = append(stats, DecadeStats{Decade: decade, Count: count})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Decade < stats[j].Decade
+	})
+	writeEnvelope(w, r, http.StatusOK, stats, APIMeta{Total: len(stats)})
+}
+
+// MemberCountDistribution counts how many artists have each number of members, keyed by the member count
+func MemberCountDistribution(artists []Artists) map[int]int {
+	counts := make(map[int]int)
+	for _, artist := range artists {
+		counts[len(artist.Members)]++
+	}
+	return counts
+}
+
+// DistributionEntry is one bar of the member count histogram
+type DistributionEntry struct {
+	MemberCount int `json:"memberCount"`
+	BandCount   int `json:"bandCount"`
+}
+
+// APIMemberCountDistributionHandler responds with the number of artists per member count as JSON, smallest count first
+func (a *App) APIMemberCountDistributionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	counts := MemberCountDistribution(a.artists())
+	distribution := make([]DistributionEntry, 0, len(counts))
+	for members, bands := range counts {
+		distribution = append(distribution, DistributionEntry{MemberCount: members, BandCount: bands})
+	}
+	sort.Slice(distribution, func(i, j int) bool {
+		return distribution[i].MemberCount < distribution[j].MemberCount
+	})
+	writeEnvelope(w, r, http.StatusOK, distribution, APIMeta{Total: len(distribution)})
+}
+
+// CareerStat is how many years an artist has been active since its creation
+type CareerStat struct {
+	ArtistID     int    `json:"artistId"`
+	ArtistName   string `json:"artistName"`
+	CreationDate int    `json:"creationDate"`
+	YearsActive  int    `json:"yearsActive"`
+}
+
+// ComputeCareers returns the career length of every artist as of currentYear, longest career first
+// careers of the same length are sorted by name so the order is stable
+func ComputeCareers(artists []Artists, currentYear int) []CareerStat {
+	careers := make([]CareerStat, 0, len(artists))
+	for _, artist := range artists {
+		careers = append(careers, CareerStat{
+			ArtistID:     artist.ID,
+			ArtistName:   artist.Name,
+			CreationDate: artist.CreationDate,
+			YearsActive:  currentYear - artist.CreationDate,
+		})
+	}
+	sort.Slice(careers, func(i, j int) bool {
+		if careers[i].YearsActive != careers[j].YearsActive {
+			return careers[i].YearsActive > careers[j].YearsActive
+		}
+		return careers[i].ArtistName < careers[j].ArtistName
+	})
+	return careers
+}
+
+// APILongestCareerHandler responds with the artists with the longest careers as JSON
+// the limit query parameter sets how many are returned, between 1 and 50, 10 by default
+func (a *App) APILongestCareerHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	limit, err := intParam(r.URL.Query(), "limit", 10)
+	if err == nil && (limit < 1 || limit > 50) {
+		err = fmt.Errorf("limit must be between 1 and 50")
+	}
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	careers := ComputeCareers(a.artists(), time.Now().Year())
+	if limit < len(careers) {
+		careers = careers[:limit]
+	}
+	writeEnvelope(w, r, http.StatusOK, careers, APIMeta{Total: len(careers)})
+}
+
+// SharedMember is a musician who is a member of two or more artists
+type SharedMember struct {
+	MemberName string   `json:"memberName"`
+	BandIDs    []int    `json:"bandIds"`
+	BandNames  []string `json:"bandNames"`
+}
+
+// FindSharedMembers returns the members appearing in two or more artists, sorted by name
+// names are compared case insensitively and trimmed, the spelling of the first artist listing them is kept
+func FindSharedMembers(artists []Artists) []SharedMember {
+	byName := make(map[string]*SharedMember)
+	var order []string
+	for _, artist := range artists {
+		for _, member := range artist.Members {
+			key := strings.ToLower(strings.TrimSpace(member))
+			shared, ok := byName[key]
+			if !ok {
+				shared = &SharedMember{MemberName: strings.TrimSpace(member)}
+				byName[key] = shared
+				order = append(order, key)
+			}
+			// a member listed twice in the same artist isn't shared
+			if n := len(shared.BandIDs); n > 0 && shared.BandIDs[n-1] == artist.ID {
+				continue
+			}
+			shared.BandIDs = append(shared.BandIDs, artist.ID)
+			shared.BandNames = append(shared.BandNames, artist.Name)
+		}
+	}
+
+	sort.Strings(order)
+	shared := []SharedMember{}
+	for _, key := range order {
+		if member := byName[key]; len(member.BandIDs) >= 2 {
+			shared = append(shared, *member)
+		}
+	}
+	return shared
+}
+
+// APISharedMembersHandler responds with the members shared by two or more artists as JSON
+func (a *App) APISharedMembersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	shared := FindSharedMembers(a.artists())
+	writeEnvelope(w, r, http.StatusOK, shared, APIMeta{Total: len(shared)})
+}
+
+// LocationInfo is a concert location with the number of artists who played there and how many times
+type LocationInfo struct {
+	Key           string `json:"key"`
+	DisplayName   string `json:"displayName"`
+	ArtistCount   int    `json:"artistCount"`
+	TotalConcerts int    `json:"totalConcerts"`
+}
+
+// AllLocations returns every location the artists played at, the ones with the most artists first
+// ties are broken by the number of concerts then by key
+func AllLocations(artists []Artists) []LocationInfo {
+	byKey := make(map[string]*LocationInfo)
+	for _, artist := range artists {
+		for location, dates := range artist.DatesLocations.DatesLocations {
+			info, ok := byKey[location]
+			if !ok {
+				info = &LocationInfo{Key: location, DisplayName: FormatLocation(location)}
+				byKey[location] = info
+			}
+			info.ArtistCount++
+			info.TotalConcerts += len(dates)
+		}
+	}
+
+	locations := make([]LocationInfo, 0, len(byKey))
+	for _, info := range byKey {
+		locations = append(locations, *info)
+	}
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].ArtistCount != locations[j].ArtistCount {
+			return locations[i].ArtistCount > locations[j].ArtistCount
+		}
+		if locations[i].TotalConcerts != locations[j].TotalConcerts {
+			return locations[i].TotalConcerts > locations[j].TotalConcerts
+		}
+		return locations[i].Key < locations[j].Key
+	})
+	return locations
+}
+
+// APILocationsHandler responds with every concert location and its artist count as JSON
+// the country query parameter keeps the locations of a single country, case insensitive
+func (a *App) APILocationsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	locations := AllLocations(a.artists())
+	if country := r.URL.Query().Get("country"); country != "" {
+		country = normalizeCountry(country)
+		filtered := []LocationInfo{}
+		for _, location := range locations {
+			if normalizeCountry(locationCountry(location.Key)) == country {
+				filtered = append(filtered, location)
+			}
+		}
+		locations = filtered
+	}
+	writeEnvelope(w, r, http.StatusOK, locations, APIMeta{Total: len(locations)})
+}
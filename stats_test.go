@@ -0,0 +1,214 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestFindSharedMembers(t *testing.T) {
+	tests := []struct {
+		name    string
+		artists []Artists
+		want    []SharedMember
+	}{
+		{
+			name: "no shared members",
+			artists: []Artists{
+				{ID: 1, Name: "Queen", Members: []string{"Freddie Mercury", "Brian May"}},
+				{ID: 2, Name: "Pink Floyd", Members: []string{"Roger Waters"}},
+			},
+			want: []SharedMember{},
+		},
+		{
+			name: "exact duplicates",
+			artists: []Artists{
+				{ID: 1, Name: "Cream", Members: []string{"Eric Clapton", "Ginger Baker"}},
+				{ID: 2, Name: "Blind Faith", Members: []string{"Eric Clapton", "Steve Winwood", "Ginger Baker"}},
+				{ID: 3, Name: "Derek and the Dominos", Members: []string{"Eric Clapton"}},
+			},
+			want: []SharedMember{
+				{MemberName: "Eric Clapton", BandIDs: []int{1, 2, 3}, BandNames: []string{"Cream", "Blind Faith", "Derek and the Dominos"}},
+				{MemberName: "Ginger Baker", BandIDs: []int{1, 2}, BandNames: []string{"Cream", "Blind Faith"}},
+			},
+		},
+		{
+			name: "case insensitive",
+			artists: []Artists{
+				{ID: 1, Name: "Genesis", Members: []string{"Phil Collins"}},
+				{ID: 2, Name: "Brand X", Members: []string{" PHIL COLLINS "}},
+			},
+			want: []SharedMember{
+				{MemberName: "Phil Collins", BandIDs: []int{1, 2}, BandNames: []string{"Genesis", "Brand X"}},
+			},
+		},
+		{
+			name: "listed twice in the same artist",
+			artists: []Artists{
+				{ID: 1, Name: "Queen", Members: []string{"Brian May", "brian may"}},
+			},
+			want: []SharedMember{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindSharedMembers(tt.artists); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindSharedMembers = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllLocations(t *testing.T) {
+	artists := []Artists{
+		{ID: 1, DatesLocations: Relations{DatesLocations: map[string][]string{
+			"paris-france": {"01-01-2020", "02-01-2020"},
+			"london-uk":    {"03-01-2020"},
+			"new_york-usa": {"04-01-2020"},
+		}}},
+		{ID: 2, DatesLocations: Relations{DatesLocations: map[string][]string{
+			"paris-france": {"05-01-2020"},
+			"lyon-france":  {"06-01-2020", "07-01-2020", "08-01-2020"},
+		}}},
+		{ID: 3, DatesLocations: Relations{DatesLocations: map[string][]string{
+			"paris-france": {"09-01-2020"},
+			"london-uk":    {"10-01-2020"},
+		}}},
+		{ID: 4},
+	}
+	want := []LocationInfo{
+		{Key: "paris-france", DisplayName: "Paris, France", ArtistCount: 3, TotalConcerts: 4},
+		{Key: "london-uk", DisplayName: "London, UK", ArtistCount: 2, TotalConcerts: 2},
+		{Key: "lyon-france", DisplayName: "Lyon, France", ArtistCount: 1, TotalConcerts: 3},
+		{Key: "new_york-usa", DisplayName: "New York, USA", ArtistCount: 1, TotalConcerts: 1},
+	}
+	if got := AllLocations(artists); !reflect.DeepEqual(got, want) {
+		t.Errorf("AllLocations = %+v, want %+v", got, want)
+	}
+	if got := AllLocations(nil); len(got) != 0 {
+		t.Errorf("AllLocations(nil) = %+v, want none", got)
+	}
+}
+
+func TestAPILocationsHandlerCountry(t *testing.T) {
+	handler := newTestServer(t, newTestApp(t, testArtists()))
+	tests := []struct {
+		country string
+		want    []string
+	}{
+		{"", []string{"paris-france", "london-uk"}},
+		{"France", []string{"paris-france"}},
+		{"uk", []string{"london-uk"}},
+		{"germany", []string{}},
+	}
+	for _, tt := range tests {
+		w := serve(handler, http.MethodGet, "/api/locations?country="+tt.country, jsonHeader)
+		if w.Code != http.StatusOK {
+			t.Fatalf("country %q: status = %d, want %d", tt.country, w.Code, http.StatusOK)
+		}
+		body := decodeEnvelope[[]LocationInfo](t, w)
+		keys := []string{}
+		for _, location := range body.Data {
+			keys = append(keys, location.Key)
+		}
+		if !reflect.DeepEqual(keys, tt.want) {
+			t.Errorf("country %q: locations = %v, want %v", tt.country, keys, tt.want)
+		}
+	}
+}
+
+func TestMemberCountDistribution(t *testing.T) {
+	artists := benchArtists(200)
+	artists = append(artists, Artists{ID: 1000, Name: "No members"})
+	counts := MemberCountDistribution(artists)
+	sum := 0
+	for members, bands := range counts {
+		if members < 0 || bands <= 0 {
+			t.Errorf("%d bands with %d members", bands, members)
+		}
+		sum += bands
+	}
+	if sum != len(artists) {
+		t.Errorf("the distribution sums to %d, want the %d artists", sum, len(artists))
+	}
+	if counts[0] != 1 {
+		t.Errorf("%d artists without members, want 1", counts[0])
+	}
+
+	artists = append(testArtists(), Artists{ID: 4, Members: []string{"Vince Clarke", "Andy Bell", "Martin Gore"}})
+	want := map[int]int{2: 3, 3: 1}
+	if got := MemberCountDistribution(artists); !reflect.DeepEqual(got, want) {
+		t.Errorf("MemberCountDistribution = %v, want %v", got, want)
+	}
+}
+
+func TestAPIMemberCountDistributionHandler(t *testing.T) {
+	artists := append(testArtists(), Artists{ID: 4, Name: "Prince", Members: []string{"Prince"}})
+	handler := newTestServer(t, newTestApp(t, artists))
+	w := serve(handler, http.MethodGet, "/api/stats/member-count-distribution", jsonHeader)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	want := []DistributionEntry{{MemberCount: 1, BandCount: 1}, {MemberCount: 2, BandCount: 3}}
+	if got := decodeEnvelope[[]DistributionEntry](t, w).Data; !reflect.DeepEqual(got, want) {
+		t.Errorf("distribution = %+v, want %+v", got, want)
+	}
+}
+
+func TestComputeCareers(t *testing.T) {
+	artists := []Artists{
+		{ID: 1, Name: "Queen", CreationDate: 1970},
+		{ID: 2, Name: "Pink Floyd", CreationDate: 1965},
+		{ID: 3, Name: "Daft Punk", CreationDate: 1993},
+		{ID: 4, Name: "Genesis", CreationDate: 1967},
+		{ID: 5, Name: "Elton John", CreationDate: 1967},
+		{ID: 6, Name: "Billie Eilish", CreationDate: 2024},
+	}
+	want := []CareerStat{
+		{ArtistID: 2, ArtistName: "Pink Floyd", CreationDate: 1965, YearsActive: 59},
+		{ArtistID: 5, ArtistName: "Elton John", CreationDate: 1967, YearsActive: 57},
+		{ArtistID: 4, ArtistName: "Genesis", CreationDate: 1967, YearsActive: 57},
+		{ArtistID: 1, ArtistName: "Queen", CreationDate: 1970, YearsActive: 54},
+		{ArtistID: 3, ArtistName: "Daft Punk", CreationDate: 1993, YearsActive: 31},
+		{ArtistID: 6, ArtistName: "Billie Eilish", CreationDate: 2024, YearsActive: 0},
+	}
+	if got := ComputeCareers(artists, 2024); !reflect.DeepEqual(got, want) {
+		t.Errorf("ComputeCareers = %+v, want %+v", got, want)
+	}
+	if got := ComputeCareers(nil, 2024); len(got) != 0 {
+		t.Errorf("ComputeCareers(nil) = %+v, want none", got)
+	}
+}
+
+func TestAPILongestCareerHandlerLimit(t *testing.T) {
+	handler := newTestServer(t, newTestApp(t, benchArtists(60)))
+	tests := []struct {
+		query     string
+		want      int
+		wantCount int
+	}{
+		{"", http.StatusOK, 10},
+		{"?limit=3", http.StatusOK, 3},
+		{"?limit=50", http.StatusOK, 50},
+		{"?limit=0", http.StatusBadRequest, 0},
+		{"?limit=51", http.StatusBadRequest, 0},
+		{"?limit=ten", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		w := serve(handler, http.MethodGet, "/api/stats/longest-career"+tt.query, jsonHeader)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.query, w.Code, tt.want)
+			continue
+		}
+		careers := decodeEnvelope[[]CareerStat](t, w).Data
+		if len(careers) != tt.wantCount {
+			t.Errorf("%s: got %d careers, want %d", tt.query, len(careers), tt.wantCount)
+		}
+		for i := 1; i < len(careers); i++ {
+			if careers[i].YearsActive > careers[i-1].YearsActive {
+				t.Errorf("%s: the careers aren't sorted by length", tt.query)
+				break
+			}
+		}
+	}
+}
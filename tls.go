@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// autoCert is the Config.TLSCertFile value asking for Let's Encrypt certificates instead of certificate files
+const autoCert = "auto"
+
+// TLSEnabled reports whether the server should serve HTTPS
+// that's when both the certificate and key files are set, or when the certificate is "auto"
+func (c Config) TLSEnabled() bool {
+	return c.TLSCertFile == autoCert || (c.TLSCertFile != "" && c.TLSKeyFile != "")
+}
+
+// autocertManager returns the Let's Encrypt manager used when TLSCertFile is "auto"
+// certificates are only requested for the host of PublicURL and are cached in TLSCacheDir
+func (c Config) autocertManager() (*autocert.Manager, error) {
+	public, err := url.Parse(c.PublicURL)
+	if err != nil {
+		return nil, err
+	}
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(public.Hostname()),
+		Cache:      autocert.DirCache(c.TLSCacheDir),
+	}, nil
+}
+
+// HTTPSRedirect answers every request with a 301 to the same URL over HTTPS on httpsPort
+// requests a reverse proxy already received over HTTPS, as told by X-Forwarded-Proto, are served by next instead
+func HTTPSRedirect(httpsPort string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		host := r.Host
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
+
+// newRedirectServer returns the server listening on port 80 of the config's host and redirecting to HTTPS
+// the requests a proxy terminated TLS for are served by next, with Let's Encrypt it also answers the ACME http-01 challenges
+func newRedirectServer(cfg Config, manager *autocert.Manager, next http.Handler) *http.Server {
+	handler := HTTPSRedirect(cfg.Port, next)
+	if manager != nil {
+		handler = manager.HTTPHandler(handler)
+	}
+	return &http.Server{
+		Addr:    net.JoinHostPort(cfg.Host, "80"),
+		Handler: handler,
+	}
+}
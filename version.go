@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+	"runtime"
+)
+
+// Version, Commit and BuildTime are set at build time with -ldflags "-X main.Version=...", see the Makefile
+// a plain go build leaves them empty and /api/version reports "dev" instead
+var Version, Commit, BuildTime string
+
+// VersionInfo is the JSON body of /api/version
+type VersionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+	GoVersion string `json:"goVersion"`
+}
+
+// orDev returns value, or "dev" when it wasn't set at build time
+func orDev(value string) string {
+	if value == "" {
+		return "dev"
+	}
+	return value
+}
+
+// buildInfo returns the version of the running binary
+func buildInfo() VersionInfo {
+	return VersionInfo{
+		Version:   orDev(Version),
+		Commit:    orDev(Commit),
+		BuildTime: orDev(BuildTime),
+		GoVersion: runtime.Version(),
+	}
+}
+
+// VersionHandler responds with the version, commit and build time of the running binary as JSON
+func (a *App) VersionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, buildInfo())
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// setBuildVars sets the ldflags variables for the duration of the test
+func setBuildVars(t *testing.T, version, commit, buildTime string) {
+	t.Helper()
+	oldVersion, oldCommit, oldBuildTime := Version, Commit, BuildTime
+	Version, Commit, BuildTime = version, commit, buildTime
+	t.Cleanup(func() {
+		Version, Commit, BuildTime = oldVersion, oldCommit, oldBuildTime
+	})
+}
+
+func TestVersionHandler(t *testing.T) {
+	tests := []struct {
+		name                       string
+		version, commit, buildTime string
+		want                       VersionInfo
+	}{
+		{
+			name: "local build",
+			want: VersionInfo{Version: "dev", Commit: "dev", BuildTime: "dev", GoVersion: runtime.Version()},
+		},
+		{
+			name:    "release build",
+			version: "v1.2.3", commit: "abc123", buildTime: "2024-01-01T00:00:00Z",
+			want: VersionInfo{Version: "v1.2.3", Commit: "abc123", BuildTime: "2024-01-01T00:00:00Z", GoVersion: runtime.Version()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setBuildVars(t, tt.version, tt.commit, tt.buildTime)
+			handler := newTestServer(t, newTestApp(t, testArtists()))
+			w := serve(handler, http.MethodGet, "/api/version", jsonHeader)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "application/json") {
+				t.Errorf("Content-Type = %q, want application/json", contentType)
+			}
+
+			// the body is exactly the four documented string fields
+			var body map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid JSON %s: %v", w.Body, err)
+			}
+			var keys []string
+			for key, value := range body {
+				keys = append(keys, key)
+				if _, ok := value.(string); !ok {
+					t.Errorf("%s = %v, want a string", key, value)
+				}
+			}
+			sort.Strings(keys)
+			if got := strings.Join(keys, ","); got != "buildTime,commit,goVersion,version" {
+				t.Errorf("keys = %s, want buildTime,commit,goVersion,version", got)
+			}
+
+			var info VersionInfo
+			if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+				t.Fatal(err)
+			}
+			if info != tt.want {
+				t.Errorf("version = %+v, want %+v", info, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchTemplates re-parses the templates from the dir directory on disk every time one of its .html files changes
+// and swaps them into *templates under mu, so template edits show up without restarting the server
+// it's meant for dev mode only, in production the embedded templates are parsed once at startup
+// the watching runs in the background, the returned error only reports a watcher that couldn't be started
+func WatchTemplates(dir string, templates *map[string]*template.Template, mu *sync.RWMutex) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("error creating template watcher: %w", err)
+	}
+
+	// fsnotify doesn't watch subdirectories on its own, so every directory is added
+	err = filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return watcher.Add(file)
+		}
+		return nil
+	})
+	if err != nil {
+		watcher.Close()
+		return fmt.Errorf("error watching templates in %s: %w", dir, err)
+	}
+
+	reload := func() {
+		parsed, err := parseTemplates(os.DirFS("."), filepath.ToSlash(dir))
+		if err != nil {
+			// the previous templates are kept so a half-saved file doesn't break every page
+			slog.Error("error reloading templates", slog.Any("error", err))
+			return
+		}
+		mu.Lock()
+		*templates = parsed
+		mu.Unlock()
+		slog.Info("templates reloaded", slog.String("dir", dir))
+	}
+	reload()
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Ext(event.Name) == ".html" && event.Op != fsnotify.Chmod {
+					reload()
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				slog.Error("template watcher error", slog.Any("error", err))
+			}
+		}
+	}()
+	return nil
+}
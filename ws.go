@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/net/websocket"
+)
+
+// maxWSClients is the number of browser tabs that can listen to /ws/refresh at the same time
+const maxWSClients = 100
+
+// refreshEvent is the message sent to the /ws/refresh clients, on connect and every time the data changes
+type refreshEvent struct {
+	Event   string `json:"event"`
+	Version string `json:"version"`
+}
+
+// wsClient is a connection to /ws/refresh, send is buffered so a slow client doesn't hold up the others
+type wsClient struct {
+	send chan []byte
+}
+
+// Hub keeps track of the /ws/refresh clients and broadcasts the data updates to them
+// the clients only ever go through its channels, register and unregister send on them without blocking once Run returned, Run owns the set
+type Hub struct {
+	Register   chan *wsClient
+	Unregister chan *wsClient
+	Broadcast  chan []byte
+
+	maxClients int
+	clients    map[*wsClient]bool
+	// done is closed when Run returns, nothing receives from the channels after that
+	done chan struct{}
+	// connected counts the accepted connections, it's checked before the upgrade so extras get a 503
+	connected atomic.Int64
+}
+
+// NewHub returns a Hub accepting up to maxClients connections, Run must be started for it to do anything
+func NewHub(maxClients int) *Hub {
+	return &Hub{
+		Register:   make(chan *wsClient),
+		Unregister: make(chan *wsClient),
+		Broadcast:  make(chan []byte, 1),
+		maxClients: maxClients,
+		clients:    make(map[*wsClient]bool),
+		done:       make(chan struct{}),
+	}
+}
+
+// Run handles the hub channels until ctx is done
+// a client whose buffer is full misses the message, the next one carries the latest version anyway
+func (h *Hub) Run(ctx context.Context) {
+	defer close(h.done)
+	for {
+		select {
+		case <-ctx.Done():
+			for client := range h.clients {
+				close(client.send)
+			}
+			return
+		case client := <-h.Register:
+			h.clients[client] = true
+		case client := <-h.Unregister:
+			if h.clients[client] {
+				delete(h.clients, client)
+				close(client.send)
+			}
+		case message := <-h.Broadcast:
+			for client := range h.clients {
+				select {
+				case client.send <- message:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// register adds client to the hub, it returns false once Run has returned
+func (h *Hub) register(client *wsClient) bool {
+	select {
+	case h.Register <- client:
+		return true
+	case <-h.done:
+		return false
+	}
+}
+
+// unregister removes client from the hub, it doesn't block once Run has returned
+func (h *Hub) unregister(client *wsClient) {
+	select {
+	case h.Unregister <- client:
+	case <-h.done:
+	}
+}
+
+// acquire reserves a connection slot, it returns false when the hub is full
+func (h *Hub) acquire() bool {
+	if h.connected.Add(1) > int64(h.maxClients) {
+		h.connected.Add(-1)
+		return false
+	}
+	return true
+}
+
+// release frees the slot taken by acquire
+func (h *Hub) release() {
+	h.connected.Add(-1)
+}
+
+// refreshMessage returns the JSON of the refresh event of the data version etag
+func refreshMessage(etag string) []byte {
+	message, _ := json.Marshal(refreshEvent{Event: "refresh", Version: strings.Trim(etag, `"`)})
+	return message
+}
+
+// notifyRefresh sends the new data version to the /ws/refresh clients without blocking the refresh
+// a pending update that wasn't picked up yet is replaced since only the latest version matters
+func (h *Hub) notifyRefresh(etag string) {
+	if h == nil {
+		return
+	}
+	message := refreshMessage(etag)
+	for {
+		select {
+		case h.Broadcast <- message:
+			return
+		default:
+		}
+		select {
+		case <-h.Broadcast:
+		default:
+		}
+	}
+}
+
+// WSRefreshHandler upgrades to a WebSocket that receives the data version on connect and on every refresh
+// only pages of this site or of the AllowedOrigins can connect, extra connections past maxWSClients get a 503
+func (a *App) WSRefreshHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(r.Context(), w, MethodNotAllowedError(), a.template("error"))
+		return
+	}
+	if a.hub == nil || !a.hub.acquire() {
+		WriteError(r.Context(), w, NewAppError(http.StatusServiceUnavailable, "Too many live connections, try again later"), a.template("error"))
+		return
+	}
+	defer a.hub.release()
+
+	server := websocket.Server{
+		Handshake: a.checkWSOrigin,
+		Handler: func(conn *websocket.Conn) {
+			defer conn.Close()
+			client := &wsClient{send: make(chan []byte, 4)}
+
+			a.artistsMu.RLock()
+			etag := a.dataETag
+			a.artistsMu.RUnlock()
+			if err := websocket.Message.Send(conn, string(refreshMessage(etag))); err != nil {
+				return
+			}
+
+			if !a.hub.register(client) {
+				return
+			}
+			// the browser never sends anything, reading only tells us when it goes away
+			go func() {
+				var ignored string
+				for websocket.Message.Receive(conn, &ignored) == nil {
+				}
+				a.hub.unregister(client)
+			}()
+			for message := range client.send {
+				if err := websocket.Message.Send(conn, string(message)); err != nil {
+					slog.Debug("error sending refresh event", slog.Any("error", err))
+				}
+			}
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+// checkWSOrigin accepts the WebSocket handshakes coming from this host or from one of the AllowedOrigins
+func (a *App) checkWSOrigin(config *websocket.Config, r *http.Request) error {
+	origin, err := websocket.Origin(config, r)
+	if err != nil {
+		return err
+	}
+	if origin == nil {
+		return fmt.Errorf("missing Origin header")
+	}
+	config.Origin = origin
+	if strings.EqualFold(origin.Host, r.Host) {
+		return nil
+	}
+	for _, allowed := range a.Config.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin.Scheme+"://"+origin.Host) {
+			return nil
+		}
+	}
+	return fmt.Errorf("origin %s isn't allowed", origin)
+}
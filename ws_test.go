@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHubShutdown(t *testing.T) {
+	hub := NewHub(maxWSClients)
+	ctx, cancel := context.WithCancel(context.Background())
+	go hub.Run(ctx)
+
+	client := &wsClient{send: make(chan []byte, 4)}
+	if !hub.register(client) {
+		t.Fatal("register = false while the hub runs")
+	}
+	hub.notifyRefresh(`"v1"`)
+	select {
+	case message := <-client.send:
+		if string(message) != `{"event":"refresh","version":"v1"}` {
+			t.Errorf("message = %s", message)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the client never got the refresh")
+	}
+
+	// once the hub stopped the clients are closed and nothing blocks on its channels
+	cancel()
+	if _, open := <-client.send; open {
+		t.Error("the client is still open after the hub stopped")
+	}
+	done := make(chan bool)
+	go func() {
+		hub.unregister(client)
+		done <- hub.register(&wsClient{send: make(chan []byte, 4)})
+	}()
+	select {
+	case registered := <-done:
+		if registered {
+			t.Error("register = true after the hub stopped")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("register or unregister blocked after the hub stopped")
+	}
+}